@@ -2,15 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/amarbel-llc/go-lib-mcp/server"
 	"github.com/amarbel-llc/go-lib-mcp/transport"
-	"github.com/friedenberg/get-hubbed/internal/tools"
 	"github.com/amarbel-llc/purse-first/purse"
+	"github.com/friedenberg/get-hubbed/internal/annotate"
+	"github.com/friedenberg/get-hubbed/internal/audit"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/gitremote"
+	"github.com/friedenberg/get-hubbed/internal/policy"
+	"github.com/friedenberg/get-hubbed/internal/redact"
+	"github.com/friedenberg/get-hubbed/internal/tools"
 )
 
 func main() {
@@ -27,26 +38,225 @@ func main() {
 		return
 	}
 
-	for _, arg := range os.Args[1:] {
-		if arg == "-h" || arg == "--help" {
-			fmt.Println("get-hubbed - a GitHub MCP server wrapping the gh CLI")
-			fmt.Println()
-			fmt.Println("Usage: get-hubbed")
-			fmt.Println()
-			fmt.Println("Runs an MCP server over stdio that exposes GitHub operations as tools.")
-			os.Exit(0)
-		}
+	flags := flag.NewFlagSet("get-hubbed", flag.ExitOnError)
+
+	backend := flags.String("backend", envOr("GET_HUBBED_BACKEND", "exec"),
+		"API backend: exec (fork gh) or http (native client; tools built on gh commands such as pr view, run view --log and pr create still run gh)")
+	record := flags.String("record", os.Getenv("GET_HUBBED_RECORD"),
+		"append every gh invocation, and every http backend API call as the gh api invocation that would make it, to this cassette file, with output redacted as by -redact")
+	replay := flags.String("replay", os.Getenv("GET_HUBBED_REPLAY"),
+		"serve gh invocations from this cassette file instead of running gh")
+	cache := flags.Bool("cache", envBool("GET_HUBBED_CACHE", true),
+		"cache REST responses in memory and revalidate them with ETags")
+	cacheDir := flags.String("cache-dir", os.Getenv("GET_HUBBED_CACHE_DIR"),
+		"also persist cached responses in this directory")
+	readOnly := flags.Bool("read-only", envBool("GET_HUBBED_READ_ONLY", false),
+		"omit every tool that can modify state on GitHub")
+	toolsets := flags.String("toolsets", os.Getenv("GET_HUBBED_TOOLSETS"),
+		"comma-separated tool groups to expose (default all: "+strings.Join(tools.ToolsetNames(), ",")+")")
+	excludeToolsets := flags.String("exclude-toolsets", os.Getenv("GET_HUBBED_EXCLUDE_TOOLSETS"),
+		"comma-separated tool groups to hide")
+	allowRepos := flags.String("allow-repos", os.Getenv("GET_HUBBED_ALLOW_REPOS"),
+		"comma-separated OWNER/REPO or HOST/OWNER/REPO globs tools may touch; calls whose repositories cannot be determined are refused, as are GraphQL queries selecting parent, forks, owner, repositories, templateRepository or node/nodes lookups. "+
+			"Limits: other GraphQL fields of an allowed repository that mention other repositories, such as cross-references, timeline events and dependency graphs, are not checked, and nor is repository data inside REST responses (default any)")
+	denyRepos := flags.String("deny-repos", os.Getenv("GET_HUBBED_DENY_REPOS"),
+		"comma-separated OWNER/REPO or HOST/OWNER/REPO globs tools may never touch")
+	defaultRepo := flags.String("repo", envOr("GET_HUBBED_REPO", os.Getenv("GH_REPO")),
+		"OWNER/REPO used when a tool call omits repo (default: inferred from the git checkout in the working directory)")
+	hostname := flags.String("hostname", envOr("GET_HUBBED_HOST", os.Getenv("GH_HOST")),
+		"GitHub host for calls that do not name one, e.g. a GitHub Enterprise Server hostname (default github.com)")
+	hosts := flags.String("hosts", os.Getenv("GET_HUBBED_HOSTS"),
+		"comma-separated further GitHub hosts tool calls may name; calls naming any other host are refused")
+	auditLog := flags.String("audit-log", os.Getenv("GET_HUBBED_AUDIT_LOG"),
+		"append a JSONL record of every tool call and the gh commands and API calls it made, with their input such as GraphQL queries and variables, to this file, with credentials masked as by -redact")
+	redaction := flags.Bool("redact", envBool("GET_HUBBED_REDACT", true),
+		"mask tokens, cloud credentials and high-entropy strings in tool output")
+	redactPatterns := flags.String("redact-patterns", os.Getenv("GET_HUBBED_REDACT_PATTERNS"),
+		"file of extra regular expressions to redact, one per line (optionally \"name: regexp\")")
+	maxOutputBytes := flags.Int("max-output-bytes", envInt("GET_HUBBED_MAX_OUTPUT_BYTES", 60_000),
+		"largest tool result in bytes before it is chunked behind a continuation cursor (0 for no limit)")
+	toolOutputBytes := flags.String("tool-output-bytes", os.Getenv("GET_HUBBED_TOOL_OUTPUT_BYTES"),
+		"comma-separated per-tool overrides of -max-output-bytes, e.g. run_log=20000,api_get=100000")
+	outputFormat := flags.String("output-format", envOr("GET_HUBBED_OUTPUT_FORMAT", tools.FormatJSON),
+		"default result format: json, compact, table, lines, yaml or toon (calls can override with output_format)")
+	rateLimitWait := flags.Duration("rate-limit-wait", envDuration("GET_HUBBED_RATE_LIMIT_WAIT", time.Minute),
+		"longest rate-limit backoff to wait out before failing the call (0 fails immediately)")
+
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintln(out, "get-hubbed - a GitHub MCP server wrapping the gh CLI")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Usage: get-hubbed [flags]")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Runs an MCP server over stdio that exposes GitHub operations as tools.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Flags:")
+		flags.PrintDefaults()
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Environment:")
+		fmt.Fprintln(out, "  GET_HUBBED_BACKEND           default for -backend")
+		fmt.Fprintln(out, "  GET_HUBBED_RECORD            default for -record")
+		fmt.Fprintln(out, "  GET_HUBBED_REPLAY            default for -replay")
+		fmt.Fprintln(out, "  GET_HUBBED_CACHE             default for -cache")
+		fmt.Fprintln(out, "  GET_HUBBED_CACHE_DIR         default for -cache-dir")
+		fmt.Fprintln(out, "  GET_HUBBED_READ_ONLY         default for -read-only")
+		fmt.Fprintln(out, "  GET_HUBBED_TOOLSETS          default for -toolsets")
+		fmt.Fprintln(out, "  GET_HUBBED_EXCLUDE_TOOLSETS  default for -exclude-toolsets")
+		fmt.Fprintln(out, "  GET_HUBBED_ALLOW_REPOS       default for -allow-repos")
+		fmt.Fprintln(out, "  GET_HUBBED_DENY_REPOS        default for -deny-repos")
+		fmt.Fprintln(out, "  GET_HUBBED_RATE_LIMIT_WAIT   default for -rate-limit-wait")
+		fmt.Fprintln(out, "  GET_HUBBED_REPO, GH_REPO     default for -repo")
+		fmt.Fprintln(out, "  GET_HUBBED_HOST, GH_HOST     default for -hostname")
+		fmt.Fprintln(out, "  GET_HUBBED_HOSTS             default for -hosts")
+		fmt.Fprintln(out, "  GET_HUBBED_AUDIT_LOG         default for -audit-log")
+		fmt.Fprintln(out, "  GET_HUBBED_REDACT            default for -redact")
+		fmt.Fprintln(out, "  GET_HUBBED_REDACT_PATTERNS   default for -redact-patterns")
+		fmt.Fprintln(out, "  GET_HUBBED_MAX_OUTPUT_BYTES  default for -max-output-bytes")
+		fmt.Fprintln(out, "  GET_HUBBED_TOOL_OUTPUT_BYTES default for -tool-output-bytes")
+		fmt.Fprintln(out, "  GET_HUBBED_OUTPUT_FORMAT     default for -output-format")
 	}
 
+	flags.Parse(os.Args[1:])
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	t := transport.NewStdio(os.Stdin, os.Stdout)
+	gh.SetRateLimitPolicy(gh.RateLimitPolicy{MaxWait: *rateLimitWait, MaxRetries: 3})
+
+	var redactor *redact.Redactor
+	if *redaction {
+		var extra []redact.Pattern
+
+		if *redactPatterns != "" {
+			var err error
+
+			extra, err = redact.LoadPatterns(*redactPatterns)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+
+		redactor = redact.New(extra)
+	}
+
+	if *record != "" && *replay != "" {
+		log.Fatalf("-record and -replay are mutually exclusive")
+	}
+
+	switch {
+	case *record != "":
+		// Cassettes hold raw API responses, so they get the same
+		// owner-only mode as the response cache.
+		f, err := os.OpenFile(*record, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatalf("opening cassette: %v", err)
+		}
+		defer f.Close()
+
+		recorder := gh.NewRecordingRunner(gh.ExecRunner{}, f)
+		if redactor != nil {
+			recorder.Redact = redactor.Redact
+		}
+
+		gh.SetRunner(recorder)
+
+	case *replay != "":
+		invocations, err := gh.LoadCassette(*replay)
+		if err != nil {
+			log.Fatalf("loading cassette: %v", err)
+		}
+
+		gh.SetRunner(gh.NewReplayRunner(invocations))
+	}
+
+	switch *backend {
+	case "exec":
+	case "http":
+		// Recording works with either backend: HTTPClient hands each API
+		// call to the recording runner as the gh api invocation that
+		// would have made it. Replaying serves those invocations to gh.
+		if *replay != "" {
+			log.Fatalf("-replay requires the exec backend")
+		}
+
+		gh.SetDefault(gh.NewHostRouter(*hostname, func(ctx context.Context, host string) (gh.Client, error) {
+			return gh.NewHTTPClient(ctx, host)
+		}))
+	default:
+		log.Fatalf("unknown backend %q", *backend)
+	}
+
+	if *cache {
+		responseCache, err := gh.NewResponseCache(*cacheDir)
+		if err != nil {
+			log.Fatalf("creating response cache: %v", err)
+		}
+
+		gh.SetDefault(gh.NewCachingClient(gh.Default(), responseCache))
+	}
+
+	repoPolicy, err := policy.New(splitList(*allowRepos), splitList(*denyRepos), *hostname)
+	if err != nil {
+		log.Fatalf("parsing repository policy: %v", err)
+	}
+
+	if *defaultRepo == "" {
+		if repo, err := gitremote.DefaultRepo(ctx, "."); err == nil {
+			*defaultRepo = repo.FullName()
+
+			if repo.Host != "github.com" {
+				*defaultRepo = repo.Host + "/" + *defaultRepo
+			}
+		}
+	}
+
+	var auditWriter *audit.Log
+	if *auditLog != "" {
+		auditWriter, err = audit.Open(*auditLog)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer auditWriter.Close()
+	}
+
+	toolBudgets, err := parseBudgets(*toolOutputBytes)
+	if err != nil {
+		log.Fatalf("parsing -tool-output-bytes: %v", err)
+	}
+
+	registry, err := tools.RegisterAll(tools.Options{
+		ReadOnly:          *readOnly,
+		Toolsets:          splitList(*toolsets),
+		ExcludeToolsets:   splitList(*excludeToolsets),
+		Policy:            repoPolicy,
+		DefaultRepo:       *defaultRepo,
+		DefaultHost:       *hostname,
+		Hosts:             splitList(*hosts),
+		Audit:             auditWriter,
+		Redactor:          redactor,
+		OutputBudget:      *maxOutputBytes,
+		ToolOutputBudgets: toolBudgets,
+		OutputFormat:      *outputFormat,
+	})
+	if err != nil {
+		log.Fatalf("registering tools: %v", err)
+	}
+
+	outputSchemas := tools.OutputSchemas()
+
+	metadata := make(map[string]annotate.Tool)
+	for name, a := range tools.Annotations() {
+		annotations, _ := json.Marshal(a)
+		metadata[name] = annotate.Tool{Annotations: annotations, OutputSchema: outputSchemas[name]}
+	}
+
+	out := annotate.NewWriter(os.Stdout, metadata)
+	t := transport.NewStdio(out.Requests(os.Stdin), out)
 
 	srv, err := server.New(t, server.Options{
 		ServerName:    "get-hubbed",
 		ServerVersion: "0.1.0",
-		Tools:         tools.RegisterAll(),
+		Tools:         registry,
 	})
 	if err != nil {
 		log.Fatalf("creating server: %v", err)
@@ -56,3 +266,86 @@ func main() {
 		log.Fatalf("server error: %v", err)
 	}
 }
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("parsing %s: %v", name, err)
+	}
+
+	return d
+}
+
+func envBool(name string, fallback bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("parsing %s: %v", name, err)
+	}
+
+	return b
+}
+
+func envInt(name string, fallback int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("parsing %s: %v", name, err)
+	}
+
+	return n
+}
+
+// parseBudgets parses "tool=bytes,tool=bytes".
+func parseBudgets(s string) (map[string]int, error) {
+	budgets := make(map[string]int)
+
+	for _, item := range splitList(s) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not TOOL=BYTES", item)
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q: budget must be a non-negative integer", item)
+		}
+
+		budgets[strings.TrimSpace(name)] = n
+	}
+
+	return budgets, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
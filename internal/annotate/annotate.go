@@ -0,0 +1,263 @@
+// Package annotate adds the MCP tool metadata and result fields the MCP
+// library has no way to declare: annotations and outputSchema on each tool
+// in a tools/list response, and structuredContent on tool results.
+package annotate
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// StructuredContentType marks a content block whose text is a result's
+// structured content. Writer moves such a block out of "content" into
+// "structuredContent", so clients never see it as content.
+const StructuredContentType = "get-hubbed/structured"
+
+// Tool is the metadata added to a tool's tools/list entry.
+type Tool struct {
+	Annotations  json.RawMessage
+	OutputSchema json.RawMessage
+}
+
+// Writer passes newline-delimited JSON-RPC messages through to w, adding
+// tool metadata to tools/list results and structured content to tool
+// results. It recognizes tools/list responses by the ids of the requests
+// seen through Requests.
+type Writer struct {
+	w     io.Writer
+	tools map[string]Tool
+
+	mu  sync.Mutex
+	buf []byte
+
+	pendingMu sync.Mutex
+	pending   map[string]bool
+}
+
+func NewWriter(w io.Writer, tools map[string]Tool) *Writer {
+	return &Writer{w: w, tools: tools, pending: make(map[string]bool)}
+}
+
+// Requests returns r with the ids of its tools/list requests noted, so the
+// responses to them get tool metadata. The server must read its requests
+// through it.
+func (a *Writer) Requests(r io.Reader) io.Reader {
+	return &requestReader{r: r, a: a}
+}
+
+type requestReader struct {
+	r   io.Reader
+	a   *Writer
+	buf []byte
+}
+
+func (rr *requestReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[:n]...)
+
+	for {
+		i := bytes.IndexByte(rr.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		rr.a.noteRequest(rr.buf[:i])
+		rr.buf = rr.buf[i+1:]
+	}
+
+	return n, err
+}
+
+func (a *Writer) noteRequest(line []byte) {
+	var request struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+
+	if err := json.Unmarshal(line, &request); err != nil || request.Method != "tools/list" || request.ID == nil {
+		return
+	}
+
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+
+	a.pending[compactID(request.ID)] = true
+}
+
+// answersToolsList reports whether line is the response to a noted
+// tools/list request, forgetting the request if so.
+func (a *Writer) answersToolsList(line []byte) bool {
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+
+	if len(a.pending) == 0 {
+		return false
+	}
+
+	var response struct {
+		ID json.RawMessage `json:"id"`
+	}
+
+	if err := json.Unmarshal(line, &response); err != nil || response.ID == nil {
+		return false
+	}
+
+	id := compactID(response.ID)
+	if !a.pending[id] {
+		return false
+	}
+
+	delete(a.pending, id)
+
+	return true
+}
+
+func compactID(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return string(id)
+	}
+
+	return buf.String()
+}
+
+func (a *Writer) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.buf = append(a.buf, p...)
+
+	for {
+		i := bytes.IndexByte(a.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		line := a.rewrite(a.buf[:i])
+		line = append(line, '\n')
+
+		if _, err := a.w.Write(line); err != nil {
+			return 0, err
+		}
+
+		a.buf = a.buf[i+1:]
+	}
+}
+
+var structuredMarker = []byte(`"` + StructuredContentType + `"`)
+
+// rewrite returns line with metadata added, or a copy of it unchanged when
+// it is not a message this writer touches.
+func (a *Writer) rewrite(line []byte) []byte {
+	listsTools := a.answersToolsList(line)
+	hasStructured := bytes.Contains(line, structuredMarker)
+
+	if !listsTools && !hasStructured {
+		return bytes.Clone(line)
+	}
+
+	var message map[string]json.RawMessage
+	if err := json.Unmarshal(line, &message); err != nil {
+		return bytes.Clone(line)
+	}
+
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(message["result"], &result); err != nil {
+		return bytes.Clone(line)
+	}
+
+	var changed bool
+	if listsTools {
+		changed = a.addToolMetadata(result)
+	} else {
+		changed = moveStructuredContent(result)
+	}
+
+	if !changed {
+		return bytes.Clone(line)
+	}
+
+	var err error
+	if message["result"], err = json.Marshal(result); err != nil {
+		return bytes.Clone(line)
+	}
+
+	out, err := json.Marshal(message)
+	if err != nil {
+		return bytes.Clone(line)
+	}
+
+	return out
+}
+
+func (a *Writer) addToolMetadata(result map[string]json.RawMessage) bool {
+	var tools []map[string]json.RawMessage
+	if err := json.Unmarshal(result["tools"], &tools); err != nil {
+		return false
+	}
+
+	for _, tool := range tools {
+		var name string
+		_ = json.Unmarshal(tool["name"], &name)
+
+		meta, ok := a.tools[name]
+		if !ok {
+			continue
+		}
+
+		if _, present := tool["annotations"]; !present && meta.Annotations != nil {
+			tool["annotations"] = meta.Annotations
+		}
+
+		if _, present := tool["outputSchema"]; !present && meta.OutputSchema != nil {
+			tool["outputSchema"] = meta.OutputSchema
+		}
+	}
+
+	var err error
+	result["tools"], err = json.Marshal(tools)
+
+	return err == nil
+}
+
+func moveStructuredContent(result map[string]json.RawMessage) bool {
+	var content []map[string]json.RawMessage
+	if err := json.Unmarshal(result["content"], &content); err != nil {
+		return false
+	}
+
+	var structured json.RawMessage
+
+	kept := make([]map[string]json.RawMessage, 0, len(content))
+	for _, block := range content {
+		var typ, text string
+		_ = json.Unmarshal(block["type"], &typ)
+
+		if typ != StructuredContentType {
+			kept = append(kept, block)
+			continue
+		}
+
+		_ = json.Unmarshal(block["text"], &text)
+		if json.Valid([]byte(text)) {
+			structured = json.RawMessage(text)
+		}
+	}
+
+	if len(kept) == len(content) {
+		return false
+	}
+
+	var err error
+	if result["content"], err = json.Marshal(kept); err != nil {
+		return false
+	}
+
+	if structured != nil {
+		result["structuredContent"] = structured
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package annotate
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriterAnnotatesToolsList(t *testing.T) {
+	var out bytes.Buffer
+
+	w := NewWriter(&out, map[string]Tool{
+		"repo_view": {
+			Annotations:  json.RawMessage(`{"readOnlyHint":true}`),
+			OutputSchema: json.RawMessage(`{"type":"object"}`),
+		},
+	})
+
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n"
+	if _, err := io.ReadAll(w.Requests(strings.NewReader(request))); err != nil {
+		t.Fatal(err)
+	}
+
+	toolsList := `{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"repo_view","inputSchema":{}},{"name":"other","inputSchema":{}}]}}` + "\n"
+	// A tool result that mentions inputSchema is not a tools/list response.
+	other := `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"{\"tools\":[{\"name\":\"repo_view\",\"inputSchema\":{}}]}"}]}}` + "\n"
+
+	// Split a message across writes, as a buffered encoder may.
+	for _, chunk := range []string{toolsList[:20], toolsList[20:] + other} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(out.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), out.String())
+	}
+
+	var message struct {
+		Result struct {
+			Tools []struct {
+				Name         string          `json:"name"`
+				Annotations  json.RawMessage `json:"annotations"`
+				OutputSchema json.RawMessage `json:"outputSchema"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(lines[0], &message); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(message.Result.Tools[0].Annotations); got != `{"readOnlyHint":true}` {
+		t.Errorf("repo_view annotations = %s", got)
+	}
+
+	if got := string(message.Result.Tools[0].OutputSchema); got != `{"type":"object"}` {
+		t.Errorf("repo_view outputSchema = %s", got)
+	}
+
+	if message.Result.Tools[1].Annotations != nil {
+		t.Errorf("unknown tool annotated: %s", message.Result.Tools[1].Annotations)
+	}
+
+	if string(lines[1])+"\n" != other {
+		t.Errorf("unrelated message changed: %s", lines[1])
+	}
+}
+
+func TestWriterMovesStructuredContent(t *testing.T) {
+	var out bytes.Buffer
+
+	w := NewWriter(&out, nil)
+
+	line := `{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"{\"number\":7}"},{"type":"` + StructuredContentType + `","text":"{\"number\":7}"}]}}` + "\n"
+
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatal(err)
+	}
+
+	var message struct {
+		Result struct {
+			Content           []map[string]string `json:"content"`
+			StructuredContent json.RawMessage     `json:"structuredContent"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(out.Bytes(), &message); err != nil {
+		t.Fatalf("%v: %s", err, out.String())
+	}
+
+	if len(message.Result.Content) != 1 || message.Result.Content[0]["type"] != "text" {
+		t.Errorf("content = %v", message.Result.Content)
+	}
+
+	if got := string(message.Result.StructuredContent); got != `{"number":7}` {
+		t.Errorf("structuredContent = %s", got)
+	}
+}
@@ -0,0 +1,94 @@
+// Package audit writes an append-only JSONL record of tool calls.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is one tool call: what was asked, which gh commands it ran and
+// how it ended.
+type Entry struct {
+	Time        time.Time       `json:"time"`
+	Tool        string          `json:"tool"`
+	Arguments   json.RawMessage `json:"arguments"`
+	GH          []Command       `json:"gh"`
+	DurationMS  int64           `json:"duration_ms"`
+	IsError     bool            `json:"is_error"`
+	OutputBytes int             `json:"output_bytes"`
+}
+
+// Command is a gh execution made while handling a call. Input is what it
+// read from stdin, such as a GraphQL query and its variables.
+type Command struct {
+	Args     []string `json:"args"`
+	Host     string   `json:"host,omitempty"`
+	Input    string   `json:"input,omitempty"`
+	ExitCode int      `json:"exit_code"`
+}
+
+type Log struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	enc    *json.Encoder
+}
+
+// Open appends to the log at path, creating it if needed.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+
+	l := New(f)
+	l.closer = f
+
+	return l, nil
+}
+
+func New(w io.Writer) *Log {
+	return &Log{w: w, enc: json.NewEncoder(w)}
+}
+
+// Write appends e as one line. Arguments that are not valid JSON are
+// recorded as a string so the line stays parseable.
+func (l *Log) Write(e Entry) error {
+	if !json.Valid(e.Arguments) {
+		quoted, err := json.Marshal(string(e.Arguments))
+		if err != nil {
+			return err
+		}
+
+		e.Arguments = quoted
+	}
+
+	if e.GH == nil {
+		e.GH = []Command{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.enc.Encode(e); err != nil {
+		return fmt.Errorf("writing audit entry: %w", err)
+	}
+
+	if f, ok := l.w.(*os.File); ok {
+		return f.Sync()
+	}
+
+	return nil
+}
+
+func (l *Log) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+
+	return l.closer.Close()
+}
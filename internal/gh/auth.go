@@ -0,0 +1,130 @@
+package gh
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveToken finds a token for host the same way gh does: environment
+// variables first, then `gh auth token`, then gh's hosts.yml.
+func ResolveToken(ctx context.Context, host string) (string, error) {
+	if host = NormalizeHost(host); host == "" {
+		host = defaultHost
+	}
+
+	envVars := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if host != defaultHost {
+		envVars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+
+	for _, name := range envVars {
+		if token := os.Getenv(name); token != "" {
+			return token, nil
+		}
+	}
+
+	if stdout, _, err := execGH(ctx, nil, []string{"auth", "token", "--hostname", host}); err == nil {
+		if token := strings.TrimSpace(stdout); token != "" {
+			return token, nil
+		}
+	}
+
+	token, err := hostsFileToken(filepath.Join(configDir(), "hosts.yml"), host)
+	if err != nil {
+		return "", err
+	}
+
+	if token == "" {
+		return "", fmt.Errorf("no token found for %s: set GH_TOKEN or run `gh auth login`", host)
+	}
+
+	return token, nil
+}
+
+func configDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".config", "gh")
+}
+
+// hostsFileToken reads the oauth_token directly under host in gh's
+// hosts.yml. Only the small subset of YAML that gh writes is understood.
+func hostsFileToken(path, host string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer f.Close()
+
+	inHost := false
+	childIndent := -1
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		if indent == 0 {
+			key, _, _ := strings.Cut(trimmed, ":")
+			inHost = unquote(key) == host
+			childIndent = -1
+
+			continue
+		}
+
+		if !inHost {
+			continue
+		}
+
+		if childIndent == -1 {
+			childIndent = indent
+		}
+
+		if indent != childIndent {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if ok && key == "oauth_token" {
+			return unquote(strings.TrimSpace(value)), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return "", nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
@@ -0,0 +1,189 @@
+package gh
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// IsCommitSHA reports whether ref is a full commit SHA, i.e. whether
+// anything fetched at ref can never change.
+func IsCommitSHA(ref string) bool {
+	return commitSHAPattern.MatchString(ref)
+}
+
+type cacheEntry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	ETag       string      `json:"etag,omitempty"`
+	Immutable  bool        `json:"immutable,omitempty"`
+	Version    string      `json:"version,omitempty"`
+}
+
+func (e *cacheEntry) response() *Response {
+	return &Response{
+		StatusCode: e.StatusCode,
+		Header:     e.Header.Clone(),
+		Body:       e.Body,
+	}
+}
+
+const maxCacheEntries = 1024
+
+// ResponseCache holds REST responses in memory and, when Dir is set, on
+// disk so they survive restarts.
+type ResponseCache struct {
+	Dir string
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	order   []string
+}
+
+func NewResponseCache(dir string) (*ResponseCache, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ResponseCache{Dir: dir, entries: make(map[string]*cacheEntry)}, nil
+}
+
+func (c *ResponseCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		return entry, true
+	}
+
+	if c.Dir == "" {
+		return nil, false
+	}
+
+	raw, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, false
+	}
+
+	c.putLocked(key, &entry)
+
+	return &entry, true
+}
+
+func (c *ResponseCache) put(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.putLocked(key, entry)
+
+	if c.Dir == "" {
+		return
+	}
+
+	// The disk copy is best effort; the in-memory entry is enough for this
+	// session.
+	if raw, err := json.Marshal(entry); err == nil {
+		_ = os.WriteFile(c.path(key), raw, 0o600)
+	}
+}
+
+func (c *ResponseCache) putLocked(key string, entry *cacheEntry) {
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+
+	c.entries[key] = entry
+
+	for len(c.order) > maxCacheEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func (c *ResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// CachingClient serves GET requests from a ResponseCache. Cached entries
+// are revalidated with If-None-Match, so unchanged resources come back as
+// 304s that do not count against the rate limit; responses to Immutable
+// requests are reused without asking GitHub at all.
+type CachingClient struct {
+	Inner Client
+	Cache *ResponseCache
+}
+
+func NewCachingClient(inner Client, cache *ResponseCache) *CachingClient {
+	return &CachingClient{Inner: inner, Cache: cache}
+}
+
+func (c *CachingClient) REST(ctx context.Context, req Request) (*Response, error) {
+	if req.Method != "" && req.Method != http.MethodGet {
+		return c.Inner.REST(ctx, req)
+	}
+
+	key := cacheKey(HostFromContext(ctx), req)
+
+	entry, cached := c.Cache.get(key)
+	if cached && (entry.Immutable || (req.Version != "" && entry.Version == req.Version)) {
+		return entry.response(), nil
+	}
+
+	if cached && entry.ETag != "" {
+		req.Headers = req.Headers.Clone()
+		if req.Headers == nil {
+			req.Headers = make(http.Header)
+		}
+
+		req.Headers.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := c.Inner.REST(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		return entry.response(), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+
+	if resp.OK() && (req.Immutable || req.Version != "" || etag != "") {
+		c.Cache.put(key, &cacheEntry{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       resp.Body,
+			ETag:       etag,
+			Immutable:  req.Immutable,
+			Version:    req.Version,
+		})
+	}
+
+	return resp, nil
+}
+
+func (c *CachingClient) GraphQL(ctx context.Context, query string, variables map[string]any) (*Response, error) {
+	return c.Inner.GraphQL(ctx, query, variables)
+}
+
+func cacheKey(host string, req Request) string {
+	return host + "\x00" + req.Path + "?" + req.Params.Encode() + "\x00" + req.Headers.Get("Accept")
+}
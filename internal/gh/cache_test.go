@@ -0,0 +1,131 @@
+package gh
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type scriptedClient struct {
+	requests  []Request
+	responses []*Response
+}
+
+func (c *scriptedClient) REST(ctx context.Context, req Request) (*Response, error) {
+	c.requests = append(c.requests, req)
+
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+
+	return resp, nil
+}
+
+func (c *scriptedClient) GraphQL(ctx context.Context, query string, variables map[string]any) (*Response, error) {
+	panic("unexpected GraphQL call")
+}
+
+func TestCachingClientRevalidatesWithETag(t *testing.T) {
+	inner := &scriptedClient{responses: []*Response{
+		{StatusCode: http.StatusOK, Header: http.Header{"Etag": {`W/"v1"`}}, Body: []byte("first")},
+		{StatusCode: http.StatusNotModified, Header: http.Header{}},
+	}}
+
+	cache, err := NewResponseCache("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewCachingClient(inner, cache)
+	req := Request{Method: http.MethodGet, Path: "repos/octo/hello"}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.REST(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(resp.Body) != "first" || resp.StatusCode != http.StatusOK {
+			t.Errorf("call %d: got %d %q, want 200 %q", i, resp.StatusCode, resp.Body, "first")
+		}
+	}
+
+	if got := inner.requests[1].Headers.Get("If-None-Match"); got != `W/"v1"` {
+		t.Errorf("If-None-Match = %q, want %q", got, `W/"v1"`)
+	}
+
+	if req.Headers != nil {
+		t.Error("caller's request headers were modified")
+	}
+}
+
+func TestCachingClientServesImmutableWithoutRequest(t *testing.T) {
+	inner := &scriptedClient{responses: []*Response{
+		{StatusCode: http.StatusOK, Header: http.Header{}, Body: []byte("blob")},
+	}}
+
+	dir := t.TempDir()
+	req := Request{
+		Method:    http.MethodGet,
+		Path:      "repos/octo/hello/contents/main.go",
+		Immutable: true,
+	}
+
+	cache, err := NewResponseCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewCachingClient(inner, cache).REST(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh cache over the same directory must not need the network.
+	cache, err = NewResponseCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := NewCachingClient(inner, cache).REST(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(resp.Body) != "blob" || len(inner.requests) != 1 {
+		t.Errorf("got %q after %d requests, want %q after 1", resp.Body, len(inner.requests), "blob")
+	}
+}
+
+func TestCachingClientReusesSameVersion(t *testing.T) {
+	inner := &scriptedClient{responses: []*Response{
+		{StatusCode: http.StatusOK, Header: http.Header{}, Body: []byte("at abc")},
+		{StatusCode: http.StatusOK, Header: http.Header{}, Body: []byte("at def")},
+	}}
+
+	cache, err := NewResponseCache("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewCachingClient(inner, cache)
+
+	for _, tc := range []struct{ version, want string }{
+		{"abc", "at abc"},
+		{"abc", "at abc"},
+		{"def", "at def"},
+	} {
+		req := Request{Method: http.MethodGet, Path: "repos/octo/hello/pulls/1/files", Version: tc.version}
+
+		resp, err := client.REST(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(resp.Body) != tc.want {
+			t.Errorf("version %s: got %q, want %q", tc.version, resp.Body, tc.want)
+		}
+	}
+
+	if len(inner.requests) != 2 {
+		t.Errorf("made %d requests, want 2", len(inner.requests))
+	}
+}
@@ -0,0 +1,156 @@
+package gh
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// A cassette is a JSONL file with one Invocation per line, in the order
+// the invocations happened.
+
+// RecordingRunner runs gh through Inner and appends every invocation to
+// a cassette. Redact, when set, is applied to the recorded stdout and
+// stderr; the caller still gets the output unchanged.
+type RecordingRunner struct {
+	Inner  Runner
+	Redact func(string) string
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewRecordingRunner(inner Runner, w io.Writer) *RecordingRunner {
+	return &RecordingRunner{Inner: inner, enc: json.NewEncoder(w)}
+}
+
+func (r *RecordingRunner) Run(ctx context.Context, stdin []byte, args []string) (*Invocation, error) {
+	inv, err := r.Inner.Run(ctx, stdin, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Observe(inv); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// Observe appends inv to the cassette. HTTPClient calls it for every API
+// call it makes, so cassettes recorded with either backend replay the
+// same way.
+func (r *RecordingRunner) Observe(inv *Invocation) error {
+	recorded := *inv
+	if r.Redact != nil {
+		recorded.Stdout = r.Redact(recorded.Stdout)
+		recorded.Stderr = r.Redact(recorded.Stderr)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.enc.Encode(recorded); err != nil {
+		return fmt.Errorf("recording invocation: %w", err)
+	}
+
+	return nil
+}
+
+// ReplayRunner serves invocations from a cassette without running gh.
+// Repeated identical invocations are answered in recorded order, and the
+// last recording is reused once they run out.
+type ReplayRunner struct {
+	mu        sync.Mutex
+	recorded  map[string][]Invocation
+	positions map[string]int
+}
+
+func NewReplayRunner(invocations []Invocation) *ReplayRunner {
+	r := &ReplayRunner{
+		recorded:  make(map[string][]Invocation),
+		positions: make(map[string]int),
+	}
+
+	for _, inv := range invocations {
+		k := cassetteKey(inv.Host, []byte(inv.Stdin), inv.Args)
+		r.recorded[k] = append(r.recorded[k], inv)
+	}
+
+	return r
+}
+
+func (r *ReplayRunner) Run(ctx context.Context, stdin []byte, args []string) (*Invocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	host := HostFromContext(ctx)
+	k := cassetteKey(host, stdin, args)
+
+	recorded := r.recorded[k]
+	if len(recorded) == 0 {
+		return &Invocation{
+			Args:     args,
+			Host:     host,
+			Stdin:    string(stdin),
+			Stderr:   "replay: no recorded invocation in cassette",
+			ExitCode: 1,
+		}, nil
+	}
+
+	pos := r.positions[k]
+	if pos >= len(recorded) {
+		pos = len(recorded) - 1
+	} else {
+		r.positions[k] = pos + 1
+	}
+
+	inv := recorded[pos]
+
+	return &inv, nil
+}
+
+func LoadCassette(path string) ([]Invocation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening cassette: %w", err)
+	}
+	defer f.Close()
+
+	return ReadCassette(f)
+}
+
+func ReadCassette(r io.Reader) ([]Invocation, error) {
+	var invocations []Invocation
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var inv Invocation
+		if err := json.Unmarshal(scanner.Bytes(), &inv); err != nil {
+			return nil, fmt.Errorf("cassette line %d: %w", line, err)
+		}
+
+		invocations = append(invocations, inv)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+
+	return invocations, nil
+}
+
+func cassetteKey(host string, stdin []byte, args []string) string {
+	return host + "\x01" + strings.Join(args, "\x00") + "\x01" + string(stdin)
+}
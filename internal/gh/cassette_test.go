@@ -0,0 +1,154 @@
+package gh_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/gh/ghtest"
+)
+
+func TestCassetteRoundTrip(t *testing.T) {
+	fake := ghtest.NewFake(
+		gh.Invocation{Args: []string{"repo", "view", "octo/hello"}, Stdout: "{}\n"},
+		gh.Invocation{Args: []string{"repo", "view", "octo/missing"}, Stderr: "not found\n", ExitCode: 1},
+	)
+
+	var cassette bytes.Buffer
+	restore := gh.SetRunner(gh.NewRecordingRunner(fake, &cassette))
+
+	if _, err := gh.Run(context.Background(), "repo", "view", "octo/hello"); err != nil {
+		t.Fatalf("recording run: %v", err)
+	}
+
+	if _, err := gh.Run(context.Background(), "repo", "view", "octo/missing"); err == nil {
+		t.Fatal("expected recorded failure")
+	}
+
+	restore()
+
+	invocations, err := gh.ReadCassette(&cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(invocations) != 2 {
+		t.Fatalf("recorded %d invocations, want 2", len(invocations))
+	}
+
+	t.Cleanup(gh.SetRunner(gh.NewReplayRunner(invocations)))
+
+	out, err := gh.Run(context.Background(), "repo", "view", "octo/hello")
+	if err != nil || out != "{}\n" {
+		t.Errorf("replay = %q, %v; want %q", out, err, "{}\n")
+	}
+
+	if _, err := gh.Run(context.Background(), "repo", "view", "octo/missing"); err == nil {
+		t.Error("replay of failed invocation succeeded")
+	}
+
+	if _, err := gh.Run(context.Background(), "repo", "view", "octo/other"); err == nil {
+		t.Error("replay of unrecorded invocation succeeded")
+	}
+}
+
+func TestRecordingRedactsOutput(t *testing.T) {
+	fake := ghtest.NewFake(gh.Invocation{Args: []string{"run", "view", "1", "--log"}, Stdout: "token=SECRET\n"})
+
+	var cassette bytes.Buffer
+
+	recorder := gh.NewRecordingRunner(fake, &cassette)
+	recorder.Redact = func(s string) string { return strings.ReplaceAll(s, "SECRET", "[REDACTED]") }
+
+	t.Cleanup(gh.SetRunner(recorder))
+
+	out, err := gh.Run(context.Background(), "run", "view", "1", "--log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "token=SECRET\n" {
+		t.Errorf("caller got %q, want the unredacted output", out)
+	}
+
+	invocations, err := gh.ReadCassette(&cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(invocations) != 1 || invocations[0].Stdout != "token=[REDACTED]\n" {
+		t.Errorf("recorded %+v, want redacted stdout", invocations)
+	}
+}
+
+func TestReplayRepeatsInOrder(t *testing.T) {
+	args := []string{"run", "view", "1", "--json", "status"}
+
+	t.Cleanup(gh.SetRunner(gh.NewReplayRunner([]gh.Invocation{
+		{Args: args, Stdout: "queued"},
+		{Args: args, Stdout: "completed"},
+	})))
+
+	for _, want := range []string{"queued", "completed", "completed"} {
+		got, err := gh.Run(context.Background(), args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestHTTPCallsAreRecordedAndTraced(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"full_name":"octo/hello"}`))
+	}))
+	defer srv.Close()
+
+	var cassette bytes.Buffer
+	restore := gh.SetRunner(gh.NewRecordingRunner(ghtest.NewFake(), &cassette))
+
+	client := &gh.HTTPClient{BaseURL: srv.URL + "/", GraphQLURL: srv.URL + "/graphql", HTTP: srv.Client()}
+
+	trace := &gh.Trace{}
+
+	if _, err := client.REST(gh.WithTrace(context.Background(), trace), gh.Request{Path: "repos/octo/hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	restore()
+
+	want := []string{"api", "repos/octo/hello", "--method", "GET", "--include"}
+
+	if traced := trace.Invocations(); len(traced) != 1 || !slices.Equal(traced[0].Args, want) {
+		t.Errorf("traced %+v, want one call with args %q", traced, want)
+	}
+
+	invocations, err := gh.ReadCassette(&cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(invocations) != 1 || !slices.Equal(invocations[0].Args, want) {
+		t.Fatalf("recorded %+v, want one invocation with args %q", invocations, want)
+	}
+
+	t.Cleanup(gh.SetRunner(gh.NewReplayRunner(invocations)))
+
+	resp, err := gh.ExecClient{}.REST(context.Background(), gh.Request{Path: "repos/octo/hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(resp.Body) != `{"full_name":"octo/hello"}` || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("replayed %d %v %q", resp.StatusCode, resp.Header, resp.Body)
+	}
+}
@@ -0,0 +1,124 @@
+package gh
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Client is a GitHub API backend. ExecClient shells out to the gh binary,
+// HTTPClient talks to the API directly over net/http.
+type Client interface {
+	REST(ctx context.Context, req Request) (*Response, error)
+	GraphQL(ctx context.Context, query string, variables map[string]any) (*Response, error)
+}
+
+// Request describes a REST call. As with `gh api -f`, Params are sent as
+// the query string for GET requests and as a JSON body otherwise. Body,
+// when set, is sent JSON-encoded instead of Params, for payloads with
+// nested objects or arrays.
+// Immutable marks requests whose response can never change, such as
+// content at a full commit SHA, so caches may skip revalidation. Version,
+// when set, names the state the response reflects, such as a pull
+// request's head commit: caches reuse a response stored under the same
+// Version without revalidating it.
+type Request struct {
+	Method    string
+	Path      string
+	Params    url.Values
+	Body      any
+	Headers   http.Header
+	Immutable bool
+	Version   string
+}
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (r *Response) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// NextPath returns the path and query of the rel="next" Link header, or
+// the empty string on the last page.
+func (r *Response) NextPath() string {
+	for _, link := range strings.Split(r.Header.Get("Link"), ",") {
+		segments := strings.Split(link, ";")
+		if len(segments) < 2 {
+			continue
+		}
+
+		isNext := false
+		for _, attr := range segments[1:] {
+			if strings.TrimSpace(attr) == `rel="next"` {
+				isNext = true
+			}
+		}
+
+		if !isNext {
+			continue
+		}
+
+		target := strings.Trim(strings.TrimSpace(segments[0]), "<>")
+
+		u, err := url.Parse(target)
+		if err != nil {
+			return ""
+		}
+
+		path := strings.TrimPrefix(u.Path, "/api/v3")
+		if u.RawQuery != "" {
+			path += "?" + u.RawQuery
+		}
+
+		return path
+	}
+
+	return ""
+}
+
+// Paginate issues req and follows rel="next" links, calling fn for each
+// page until fn returns an error or there are no more pages.
+func Paginate(ctx context.Context, c Client, req Request, fn func(*Response) error) error {
+	for {
+		resp, err := c.REST(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(resp); err != nil {
+			return err
+		}
+
+		next := resp.NextPath()
+		if next == "" {
+			return nil
+		}
+
+		req = Request{Method: http.MethodGet, Path: next, Headers: req.Headers, Immutable: req.Immutable, Version: req.Version}
+	}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultClient Client = ExecClient{}
+)
+
+func Default() Client {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultClient
+}
+
+func SetDefault(c Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultClient = c
+}
@@ -0,0 +1,269 @@
+package gh
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrorKind classifies a failed call by what the caller should do about
+// it.
+type ErrorKind string
+
+const (
+	// KindNotFound: the resource does not exist. GitHub also answers 404
+	// for private resources the credentials cannot see.
+	KindNotFound ErrorKind = "not_found"
+	// KindAuth: credentials are missing, invalid or expired.
+	KindAuth ErrorKind = "auth"
+	// KindPermission: the credentials are valid but lack the scope, role or
+	// SSO authorization the call needs.
+	KindPermission ErrorKind = "permission"
+	// KindValidation: the request itself was rejected as malformed.
+	KindValidation  ErrorKind = "validation"
+	KindConflict    ErrorKind = "conflict"
+	KindRateLimited ErrorKind = "rate_limited"
+	KindServer      ErrorKind = "server"
+	// KindUnavailable: gh itself could not be started, typically because
+	// it is not installed or not on PATH.
+	KindUnavailable ErrorKind = "unavailable"
+	KindUnknown     ErrorKind = "unknown"
+)
+
+// Error is a failed gh execution or API call. Op names what failed: the
+// gh argv, or the method and path of an API request.
+type Error struct {
+	Kind             ErrorKind
+	Op               string
+	StatusCode       int
+	Message          string
+	DocumentationURL string
+	RateLimit        *RateLimitError
+	Err              error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.RateLimit != nil:
+		return fmt.Sprintf("%s: %v", e.Op, e.RateLimit)
+	case e.StatusCode != 0 && !strings.Contains(e.Message, fmt.Sprintf("HTTP %d", e.StatusCode)):
+		return fmt.Sprintf("%s: HTTP %d: %s", e.Op, e.StatusCode, e.Message)
+	default:
+		return fmt.Sprintf("%s: %s", e.Op, e.Message)
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// apiErrorBody is the JSON GitHub returns alongside a failing status.
+type apiErrorBody struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+	Errors           []struct {
+		Resource string `json:"resource"`
+		Field    string `json:"field"`
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+	} `json:"errors"`
+}
+
+// message folds validation details into the top-level message, since
+// "Validation Failed" alone does not say which field was wrong.
+func (b apiErrorBody) message() string {
+	var details []string
+
+	for _, e := range b.Errors {
+		switch {
+		case e.Message != "":
+			details = append(details, e.Message)
+		case e.Field != "":
+			details = append(details, fmt.Sprintf("%s.%s %s", e.Resource, e.Field, e.Code))
+		}
+	}
+
+	if len(details) == 0 {
+		return b.Message
+	}
+
+	return fmt.Sprintf("%s (%s)", b.Message, strings.Join(details, "; "))
+}
+
+func parseAPIErrorBody(body []byte) (apiErrorBody, bool) {
+	var payload apiErrorBody
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Message == "" {
+		return payload, false
+	}
+
+	return payload, true
+}
+
+// statusError returns nil for successful (and not-modified) responses and
+// an *Error describing the failure otherwise.
+func statusError(method, path string, resp *Response) error {
+	if resp.OK() || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	e := &Error{
+		Op:         method + " " + path,
+		StatusCode: resp.StatusCode,
+		Message:    truncateStderr(strings.TrimSpace(string(resp.Body))),
+	}
+
+	if payload, ok := parseAPIErrorBody(resp.Body); ok {
+		e.Message = truncateStderr(payload.message())
+		e.DocumentationURL = payload.DocumentationURL
+	}
+
+	e.Kind = classify(e.StatusCode, e.Message)
+
+	return e
+}
+
+func rateLimitedError(op string, statusCode int, rlErr *RateLimitError) *Error {
+	return &Error{
+		Kind:       KindRateLimited,
+		Op:         op,
+		StatusCode: statusCode,
+		Message:    rlErr.Message,
+		RateLimit:  rlErr,
+		Err:        rlErr,
+	}
+}
+
+var stderrStatusPattern = regexp.MustCompile(`\bHTTP (\d{3})\b`)
+
+// execError describes a failed gh execution. `gh api` prints the error
+// body on stdout and a summary with the status on stderr; other commands
+// only print a message on stderr.
+func execError(args []string, stdout, stderr string, err error) *Error {
+	e := &Error{
+		Op:      fmt.Sprintf("gh %v", args),
+		Message: strings.TrimSpace(truncateStderr(stderr)),
+		Err:     err,
+	}
+
+	if m := stderrStatusPattern.FindStringSubmatch(stderr); m != nil {
+		e.StatusCode, _ = strconv.Atoi(m[1])
+	}
+
+	if payload, ok := parseAPIErrorBody([]byte(stdout)); ok {
+		e.Message = truncateStderr(payload.message())
+		e.DocumentationURL = payload.DocumentationURL
+	}
+
+	if e.Message == "" {
+		e.Message = err.Error()
+	}
+
+	// Checked first: the message of a missing binary, "executable file not
+	// found in $PATH", would otherwise read as a missing resource.
+	var startErr *exec.Error
+	if errors.Is(err, exec.ErrNotFound) || errors.As(err, &startErr) {
+		e.Kind = KindUnavailable
+
+		return e
+	}
+
+	e.Kind = classify(e.StatusCode, e.Message)
+
+	return e
+}
+
+var (
+	// scopePattern matches GitHub and gh's wording for a token missing an
+	// OAuth scope, without catching every message that mentions a scope.
+	scopePattern = regexp.MustCompile(`\b(?:missing|required|granted|needs the \S+) scopes?\b`)
+	// invalidPattern matches rejected input rather than any use of the
+	// word, such as "invalid character" from a garbled response.
+	invalidPattern = regexp.MustCompile(`\binvalid (?:argument|value|flag|field|parameter|request|input)\b`)
+)
+
+func classify(statusCode int, message string) ErrorKind {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return KindAuth
+	case statusCode == http.StatusNotFound, statusCode == http.StatusGone:
+		return KindNotFound
+	case statusCode == http.StatusForbidden:
+		return KindPermission
+	case statusCode == http.StatusConflict:
+		return KindConflict
+	case statusCode == http.StatusBadRequest, statusCode == http.StatusUnprocessableEntity:
+		return KindValidation
+	case statusCode >= 500:
+		return KindServer
+	}
+
+	lower := strings.ToLower(message)
+
+	switch {
+	case strings.Contains(lower, "could not resolve to"),
+		strings.Contains(lower, "not found"),
+		strings.Contains(lower, "no commit found"):
+		return KindNotFound
+	case strings.Contains(lower, "gh auth login"),
+		strings.Contains(lower, "bad credentials"),
+		strings.Contains(lower, "requires authentication"),
+		strings.Contains(lower, "not logged in"):
+		return KindAuth
+	case strings.Contains(lower, "resource not accessible"),
+		scopePattern.MatchString(lower),
+		strings.Contains(lower, "saml"),
+		strings.Contains(lower, "permission"):
+		return KindPermission
+	case strings.Contains(lower, "validation failed"),
+		invalidPattern.MatchString(lower),
+		strings.Contains(lower, "unknown flag"),
+		strings.Contains(lower, "parse error"),
+		strings.Contains(lower, "jq expression"):
+		return KindValidation
+	}
+
+	return KindUnknown
+}
+
+// graphQLErrorKinds maps the type GitHub puts on GraphQL errors.
+var graphQLErrorKinds = map[string]ErrorKind{
+	"NOT_FOUND":           KindNotFound,
+	"FORBIDDEN":           KindPermission,
+	"INSUFFICIENT_SCOPES": KindPermission,
+	"UNAUTHENTICATED":     KindAuth,
+	"RATE_LIMITED":        KindRateLimited,
+	"UNPROCESSABLE":       KindValidation,
+}
+
+func graphQLErrors(body []byte) error {
+	var payload struct {
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Errors) == 0 {
+		return nil
+	}
+
+	// Errors without a type are schema or syntax errors in the query.
+	kind := KindValidation
+	if k, ok := graphQLErrorKinds[payload.Errors[0].Type]; ok {
+		kind = k
+	} else if payload.Errors[0].Type != "" {
+		kind = KindUnknown
+	}
+
+	messages := make([]string, len(payload.Errors))
+	for i, e := range payload.Errors {
+		messages[i] = e.Message
+	}
+
+	return &Error{Kind: kind, Op: "graphql", Message: strings.Join(messages, "; ")}
+}
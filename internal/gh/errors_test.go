@@ -0,0 +1,108 @@
+package gh
+
+import (
+	"errors"
+	"net/http"
+	"os/exec"
+	"testing"
+)
+
+func TestExecErrorClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		stderr string
+		kind   ErrorKind
+		status int
+	}{
+		{
+			name:   "api body",
+			stdout: `{"message": "Not Found", "documentation_url": "https://docs.github.com/rest", "status": "404"}`,
+			stderr: "gh: Not Found (HTTP 404)\n",
+			kind:   KindNotFound,
+			status: 404,
+		},
+		{
+			name:   "graphql not found",
+			stderr: "GraphQL: Could not resolve to a Repository with the name 'octo/nope'. (repository)\n",
+			kind:   KindNotFound,
+		},
+		{
+			name:   "not logged in",
+			stderr: "To get started with GitHub CLI, please run:  gh auth login\n",
+			kind:   KindAuth,
+		},
+		{
+			name:   "missing scope",
+			stderr: "HTTP 403: Resource not accessible by personal access token (https://api.github.com/repos/octo/hello/issues)\n",
+			kind:   KindPermission,
+			status: 403,
+		},
+		{
+			name:   "validation",
+			stdout: `{"message": "Validation Failed", "errors": [{"resource": "Issue", "field": "title", "code": "missing_field"}]}`,
+			stderr: "gh: Validation Failed (HTTP 422)\n",
+			kind:   KindValidation,
+			status: 422,
+		},
+		{
+			name:   "missing scope in graphql",
+			stderr: "GraphQL: Your token has not been granted the required scopes to execute this query.\n",
+			kind:   KindPermission,
+		},
+		{
+			name:   "scope in an unrelated message",
+			stderr: "failed to parse scope block in workflow file\n",
+			kind:   KindUnknown,
+		},
+		{
+			name:   "invalid flag value",
+			stderr: "invalid argument \"x\" for \"-L, --limit\" flag: strconv.ParseInt: parsing \"x\": invalid syntax\n",
+			kind:   KindValidation,
+		},
+		{
+			name:   "garbled response",
+			stderr: "invalid character '<' looking for beginning of value\n",
+			kind:   KindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := execError([]string{"api", "x"}, tt.stdout, tt.stderr, &ExitError{Code: 1})
+
+			if e.Kind != tt.kind || e.StatusCode != tt.status {
+				t.Errorf("got kind %s status %d, want %s %d (message %q)", e.Kind, e.StatusCode, tt.kind, tt.status, e.Message)
+			}
+		})
+	}
+}
+
+func TestExecErrorMissingBinary(t *testing.T) {
+	err := &exec.Error{Name: "gh", Err: exec.ErrNotFound}
+
+	e := execError([]string{"repo", "view"}, "", "", err)
+	if e.Kind != KindUnavailable {
+		t.Errorf("got kind %s for %q, want %s", e.Kind, e.Message, KindUnavailable)
+	}
+}
+
+func TestStatusErrorIncludesValidationDetails(t *testing.T) {
+	resp := &Response{
+		StatusCode: http.StatusUnprocessableEntity,
+		Body:       []byte(`{"message": "Validation Failed", "errors": [{"resource": "Label", "field": "name", "code": "already_exists"}], "documentation_url": "https://docs.github.com/rest/issues/labels"}`),
+	}
+
+	var e *Error
+	if !errors.As(statusError(http.MethodPost, "repos/octo/hello/labels", resp), &e) {
+		t.Fatal("expected *Error")
+	}
+
+	if e.Kind != KindValidation || e.DocumentationURL == "" {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	if want := "POST repos/octo/hello/labels: HTTP 422: Validation Failed (Label.name already_exists)"; e.Error() != want {
+		t.Errorf("Error() = %q, want %q", e.Error(), want)
+	}
+}
@@ -3,23 +3,169 @@ package gh
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"sync"
 	"unicode/utf8"
 )
 
-func Run(ctx context.Context, args ...string) (string, error) {
+// Invocation is a single gh execution: its argv, the host it targeted
+// when not the default, its stdin and what it produced.
+type Invocation struct {
+	Args     []string `json:"args"`
+	Host     string   `json:"host,omitempty"`
+	Stdin    string   `json:"stdin,omitempty"`
+	Stdout   string   `json:"stdout"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code"`
+}
+
+// Runner executes gh. A non-zero exit is reported through ExitCode; the
+// error is reserved for failing to run gh at all.
+type Runner interface {
+	Run(ctx context.Context, stdin []byte, args []string) (*Invocation, error)
+}
+
+// ExecRunner runs the gh binary, pointing GH_HOST at the context's host
+// when one is set.
+type ExecRunner struct{}
+
+func (ExecRunner) Run(ctx context.Context, stdin []byte, args []string) (*Invocation, error) {
 	cmd := exec.CommandContext(ctx, "gh", args...)
 
+	host := HostFromContext(ctx)
+	if host != "" {
+		cmd.Env = append(os.Environ(), "GH_HOST="+host)
+	}
+
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	inv := &Invocation{Args: args, Host: host, Stdin: string(stdin)}
+
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("gh %v: %w: %s", args, err, truncateStderr(stderr.String()))
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		inv.ExitCode = exitErr.ExitCode()
+	}
+
+	inv.Stdout = stdout.String()
+	inv.Stderr = stderr.String()
+
+	return inv, nil
+}
+
+type RunnerFunc func(ctx context.Context, stdin []byte, args []string) (*Invocation, error)
+
+func (f RunnerFunc) Run(ctx context.Context, stdin []byte, args []string) (*Invocation, error) {
+	return f(ctx, stdin, args)
+}
+
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+var (
+	runnerMu sync.RWMutex
+	runner   Runner = ExecRunner{}
+)
+
+// SetRunner replaces the Runner used for every gh execution and returns a
+// function that restores the previous one.
+func SetRunner(r Runner) (restore func()) {
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+
+	prev := runner
+	runner = r
+
+	return func() {
+		runnerMu.Lock()
+		defer runnerMu.Unlock()
+
+		runner = prev
+	}
+}
+
+func currentRunner() Runner {
+	runnerMu.RLock()
+	defer runnerMu.RUnlock()
+
+	return runner
+}
+
+func Run(ctx context.Context, args ...string) (string, error) {
+	for attempt := 0; ; attempt++ {
+		stdout, stderr, err := execGH(ctx, nil, args)
+		if err == nil {
+			return stdout, nil
+		}
+
+		rlErr := rateLimitFromStderr(stderr)
+		if rlErr == nil {
+			return "", execError(args, stdout, stderr, err)
+		}
+
+		rlErr.Resource = rateLimitResource(args)
+
+		if rlErr.Kind == RateLimitPrimary {
+			lookupReset(ctx, rlErr)
+		}
+
+		if !backoff(ctx, attempt, rlErr) {
+			return "", rateLimitedError(fmt.Sprintf("gh %v", args), 0, rlErr)
+		}
+	}
+}
+
+// Observer is a Runner that also wants to see the API calls made without
+// running gh, such as HTTPClient's, each as the `gh api` invocation that
+// would have made it.
+type Observer interface {
+	Observe(inv *Invocation) error
+}
+
+// observeExchange adds an API call made without running gh to the
+// context's trace and hands it to the Runner if that is an Observer.
+func observeExchange(ctx context.Context, inv *Invocation) error {
+	traceInvocation(ctx, inv)
+
+	if o, ok := currentRunner().(Observer); ok {
+		return o.Observe(inv)
+	}
+
+	return nil
+}
+
+func execGH(ctx context.Context, stdin []byte, args []string) (string, string, error) {
+	inv, err := currentRunner().Run(ctx, stdin, args)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Trace what was sent, whatever the Runner reports.
+	inv.Stdin = string(stdin)
+	traceInvocation(ctx, inv)
+
+	if inv.ExitCode != 0 {
+		return inv.Stdout, inv.Stderr, &ExitError{Code: inv.ExitCode}
 	}
 
-	return stdout.String(), nil
+	return inv.Stdout, inv.Stderr, nil
 }
 
 const maxStderrBytes = 100_000
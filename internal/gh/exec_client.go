@@ -0,0 +1,171 @@
+package gh
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ExecClient implements Client by running `gh api --include` and parsing
+// the status line and headers it prints ahead of the body. A host set on
+// the call's context takes precedence over Host.
+type ExecClient struct {
+	Host string
+}
+
+func (c ExecClient) REST(ctx context.Context, req Request) (*Response, error) {
+	method, args, stdin, err := restArgs(c.host(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doWithBackoff(ctx, method+" "+req.Path, func() (*Response, error) {
+		return c.do(ctx, stdin, args)
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, statusError(method, req.Path, resp)
+}
+
+// restArgs returns the method of req and the `gh api` arguments and stdin
+// that make it.
+func restArgs(host string, req Request) (string, []string, []byte, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	args := []string{"api", req.Path, "--method", method, "--include"}
+	args = append(args, hostArgs(host)...)
+
+	for _, k := range slices.Sorted(maps.Keys(req.Headers)) {
+		for _, v := range req.Headers[k] {
+			args = append(args, "-H", fmt.Sprintf("%s: %s", k, v))
+		}
+	}
+
+	if req.Body != nil {
+		encoded, err := json.Marshal(req.Body)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("encoding request body: %w", err)
+		}
+
+		return method, append(args, "--input", "-"), encoded, nil
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(req.Params)) {
+		for _, v := range req.Params[k] {
+			args = append(args, "-f", fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+
+	return method, args, nil, nil
+}
+
+func (c ExecClient) GraphQL(ctx context.Context, query string, variables map[string]any) (*Response, error) {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return nil, fmt.Errorf("encoding graphql request: %w", err)
+	}
+
+	args := graphQLArgs(c.host(ctx))
+
+	resp, err := doWithBackoff(ctx, "POST graphql", func() (*Response, error) {
+		return c.do(ctx, body, args)
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if err := statusError(http.MethodPost, "graphql", resp); err != nil {
+		return resp, err
+	}
+
+	return resp, graphQLErrors(resp.Body)
+}
+
+func (c ExecClient) host(ctx context.Context) string {
+	if host := HostFromContext(ctx); host != "" {
+		return host
+	}
+
+	return c.Host
+}
+
+// graphQLArgs returns the `gh api` arguments of a GraphQL request, whose
+// query and variables go on stdin.
+func graphQLArgs(host string) []string {
+	return append([]string{"api", "graphql", "--include", "--input", "-"}, hostArgs(host)...)
+}
+
+func hostArgs(host string) []string {
+	if host == "" {
+		return nil
+	}
+
+	return []string{"--hostname", host}
+}
+
+func (c ExecClient) do(ctx context.Context, stdin []byte, args []string) (*Response, error) {
+	stdout, stderr, runErr := execGH(ctx, stdin, args)
+
+	// gh exits non-zero for HTTP errors but still prints the response, so
+	// only fall back to the exec error when there is nothing to parse.
+	resp, err := parseIncludedResponse(stdout)
+	if err != nil {
+		if runErr != nil {
+			return nil, execError(args, stdout, stderr, runErr)
+		}
+
+		return nil, fmt.Errorf("gh %v: %w", args, err)
+	}
+
+	return resp, nil
+}
+
+func parseIncludedResponse(out string) (*Response, error) {
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(out)))
+
+	statusLine, err := r.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("reading status line: %w", err)
+	}
+
+	proto, status, ok := strings.Cut(statusLine, " ")
+	if !ok || !strings.HasPrefix(proto, "HTTP/") {
+		return nil, fmt.Errorf("malformed status line %q", statusLine)
+	}
+
+	code, _, _ := strings.Cut(status, " ")
+
+	statusCode, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, fmt.Errorf("malformed status line %q", statusLine)
+	}
+
+	header, err := r.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("reading headers: %w", err)
+	}
+
+	body, err := io.ReadAll(r.R)
+	if err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+
+	return &Response{
+		StatusCode: statusCode,
+		Header:     http.Header(header),
+		Body:       body,
+	}, nil
+}
@@ -0,0 +1,121 @@
+// Package ghtest provides a scripted gh Runner for tests.
+package ghtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// Fake answers gh executions from recorded invocations keyed by host and
+// argv. A recording with Stdin only matches executions given that input,
+// so GraphQL calls, whose argv is always the same, can be told apart by
+// their query and variables; one without Stdin matches any input.
+// Executions with no recording fail with exit status 1 and are collected
+// in Unmatched.
+type Fake struct {
+	mu         sync.Mutex
+	recordings map[string][]gh.Invocation
+	calls      [][]string
+	inputs     []string
+	unmatched  [][]string
+}
+
+func NewFake(invocations ...gh.Invocation) *Fake {
+	f := &Fake{recordings: make(map[string][]gh.Invocation)}
+
+	for _, inv := range invocations {
+		f.Add(inv)
+	}
+
+	return f
+}
+
+func (f *Fake) Add(inv gh.Invocation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	k := key(inv.Host, inv.Args)
+	f.recordings[k] = append(f.recordings[k], inv)
+}
+
+func (f *Fake) Run(ctx context.Context, stdin []byte, args []string) (*gh.Invocation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, args)
+	f.inputs = append(f.inputs, string(stdin))
+
+	host := gh.HostFromContext(ctx)
+
+	inv, ok := f.match(key(host, args), string(stdin))
+	if !ok {
+		f.unmatched = append(f.unmatched, args)
+
+		return &gh.Invocation{
+			Args:     args,
+			Host:     host,
+			Stderr:   fmt.Sprintf("ghtest: no recorded invocation for %q", args),
+			ExitCode: 1,
+		}, nil
+	}
+
+	inv.Args = args
+
+	return &inv, nil
+}
+
+// match returns the recording for k given stdin, preferring one recorded
+// with that exact input.
+func (f *Fake) match(k, stdin string) (gh.Invocation, bool) {
+	for _, inv := range f.recordings[k] {
+		if inv.Stdin != "" && inv.Stdin == stdin {
+			return inv, true
+		}
+	}
+
+	for _, inv := range f.recordings[k] {
+		if inv.Stdin == "" {
+			return inv, true
+		}
+	}
+
+	return gh.Invocation{}, false
+}
+
+// Calls returns the argv of every execution in order.
+func (f *Fake) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([][]string(nil), f.calls...)
+}
+
+// Inputs returns the stdin of every execution, parallel to Calls.
+func (f *Fake) Inputs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.inputs...)
+}
+
+func (f *Fake) Unmatched() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([][]string(nil), f.unmatched...)
+}
+
+// Install makes f the gh Runner for the duration of the test.
+func Install(t testing.TB, f *Fake) {
+	t.Helper()
+	t.Cleanup(gh.SetRunner(f))
+}
+
+func key(host string, args []string) string {
+	return host + "\x01" + strings.Join(args, "\x00")
+}
@@ -0,0 +1,114 @@
+package gh
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+)
+
+type hostKey struct{}
+
+// WithHost returns a context whose gh executions and API calls target
+// host instead of the default (GH_HOST, or github.com).
+func WithHost(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, hostKey{}, host)
+}
+
+// HostFromContext returns the host set by WithHost, or the empty string
+// for the default host.
+func HostFromContext(ctx context.Context) string {
+	host, _ := ctx.Value(hostKey{}).(string)
+	return host
+}
+
+// NormalizeHost reduces host to the name gh files its credentials under:
+// lowercased, without a port, a trailing dot or an api. or www. prefix,
+// with subdomains of github.com and github.localhost folded into those and
+// a GHE.com tenant reduced to TENANT.ghe.com.
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.TrimSuffix(host, ".")
+
+	for _, prefix := range []string{"api.", "www."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+
+	switch {
+	case strings.HasSuffix(host, "."+defaultHost):
+		return defaultHost
+	case strings.HasSuffix(host, ".github.localhost"):
+		return "github.localhost"
+	}
+
+	if tenant, ok := strings.CutSuffix(host, ".ghe.com"); ok {
+		return tenant[strings.LastIndex(tenant, ".")+1:] + ".ghe.com"
+	}
+
+	return host
+}
+
+// HostRouter is a Client that sends each call to a per-host client,
+// creating it with New the first time a host is seen. Calls without a
+// host in their context go to Default.
+type HostRouter struct {
+	Default string
+	New     func(ctx context.Context, host string) (Client, error)
+
+	mu      sync.Mutex
+	clients map[string]Client
+}
+
+func NewHostRouter(defaultHost string, newClient func(ctx context.Context, host string) (Client, error)) *HostRouter {
+	return &HostRouter{Default: defaultHost, New: newClient, clients: make(map[string]Client)}
+}
+
+func (r *HostRouter) client(ctx context.Context) (Client, error) {
+	host := HostFromContext(ctx)
+	if host == "" {
+		host = r.Default
+	}
+
+	if host = NormalizeHost(host); host == "" {
+		host = defaultHost
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.clients[host]; ok {
+		return c, nil
+	}
+
+	c, err := r.New(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	r.clients[host] = c
+
+	return c, nil
+}
+
+func (r *HostRouter) REST(ctx context.Context, req Request) (*Response, error) {
+	c, err := r.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.REST(ctx, req)
+}
+
+func (r *HostRouter) GraphQL(ctx context.Context, query string, variables map[string]any) (*Response, error) {
+	c, err := r.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GraphQL(ctx, query, variables)
+}
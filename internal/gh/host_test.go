@@ -0,0 +1,24 @@
+package gh
+
+import "testing"
+
+func TestNormalizeHost(t *testing.T) {
+	for host, want := range map[string]string{
+		"github.com":           "github.com",
+		"GitHub.com":           "github.com",
+		"api.github.com":       "github.com",
+		"www.github.com:443":   "github.com",
+		"github.com.":          "github.com",
+		"ghe.example.com":      "ghe.example.com",
+		"api.ghe.example.com":  "ghe.example.com",
+		"ghe.example.com:8443": "ghe.example.com",
+		"api.acme.ghe.com":     "acme.ghe.com",
+		"foo.bar.acme.ghe.com": "acme.ghe.com",
+		"api.github.localhost": "github.localhost",
+		"":                     "",
+	} {
+		if got := NormalizeHost(host); got != want {
+			t.Errorf("NormalizeHost(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultHost = "github.com"
+
+// HTTPClient implements Client natively over net/http, reusing connections
+// across calls instead of forking gh for each one.
+type HTTPClient struct {
+	BaseURL    string
+	GraphQLURL string
+	Token      string
+	HTTP       *http.Client
+}
+
+func NewHTTPClient(ctx context.Context, host string) (*HTTPClient, error) {
+	if host == "" {
+		host = defaultHost
+	}
+
+	token, err := ResolveToken(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &HTTPClient{
+		Token: token,
+		HTTP:  &http.Client{Timeout: 60 * time.Second},
+	}
+
+	if host == defaultHost {
+		c.BaseURL = "https://api.github.com/"
+		c.GraphQLURL = "https://api.github.com/graphql"
+	} else {
+		c.BaseURL = fmt.Sprintf("https://%s/api/v3/", host)
+		c.GraphQLURL = fmt.Sprintf("https://%s/api/graphql", host)
+	}
+
+	return c, nil
+}
+
+func (c *HTTPClient) REST(ctx context.Context, req Request) (*Response, error) {
+	method, args, stdin, err := restArgs(HostFromContext(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+
+	u := strings.TrimSuffix(c.BaseURL, "/") + "/" + strings.TrimPrefix(req.Path, "/")
+
+	body := stdin
+
+	if body == nil && len(req.Params) > 0 {
+		if method == http.MethodGet || method == http.MethodHead {
+			sep := "?"
+			if strings.Contains(u, "?") {
+				sep = "&"
+			}
+
+			u += sep + req.Params.Encode()
+		} else {
+			fields := make(map[string]string, len(req.Params))
+			for k := range req.Params {
+				fields[k] = req.Params.Get(k)
+			}
+
+			encoded, err := json.Marshal(fields)
+			if err != nil {
+				return nil, fmt.Errorf("encoding request body: %w", err)
+			}
+
+			body = encoded
+		}
+	}
+
+	resp, err := doWithBackoff(ctx, method+" "+req.Path, func() (*Response, error) {
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("building request: %w", err)
+		}
+
+		for k, values := range req.Headers {
+			for _, v := range values {
+				httpReq.Header.Add(k, v)
+			}
+		}
+
+		return c.do(ctx, httpReq, args, stdin)
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, statusError(method, req.Path, resp)
+}
+
+func (c *HTTPClient) GraphQL(ctx context.Context, query string, variables map[string]any) (*Response, error) {
+	encoded, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return nil, fmt.Errorf("encoding graphql request: %w", err)
+	}
+
+	args := graphQLArgs(HostFromContext(ctx))
+
+	resp, err := doWithBackoff(ctx, "POST graphql", func() (*Response, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.GraphQLURL, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("building request: %w", err)
+		}
+
+		return c.do(ctx, httpReq, args, encoded)
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if err := statusError(http.MethodPost, "graphql", resp); err != nil {
+		return resp, err
+	}
+
+	return resp, graphQLErrors(resp.Body)
+}
+
+// do sends req and reports the exchange to the trace and recorder as the
+// `gh api` invocation, args and stdin, that would have made it, so audit
+// logs and cassettes read the same under either backend.
+func (c *HTTPClient) do(ctx context.Context, req *http.Request, args []string, stdin []byte) (*Response, error) {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	inv := &Invocation{
+		Args:   args,
+		Host:   HostFromContext(ctx),
+		Stdin:  string(stdin),
+		Stdout: includedResponse(resp, body),
+	}
+
+	// gh api exits non-zero for HTTP errors and GraphQL errors alike.
+	if resp.StatusCode >= 400 || (req.URL.String() == c.GraphQLURL && graphQLErrors(body) != nil) {
+		inv.ExitCode = 1
+	}
+
+	if err := observeExchange(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
+}
+
+// includedResponse renders resp the way `gh api --include` prints it.
+func includedResponse(resp *http.Response, body []byte) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s %s\r\n", resp.Proto, resp.Status)
+
+	for _, k := range slices.Sorted(maps.Keys(resp.Header)) {
+		for _, v := range resp.Header[k] {
+			fmt.Fprintf(&sb, "%s: %s\r\n", k, v)
+		}
+	}
+
+	sb.WriteString("\r\n")
+	sb.Write(body)
+
+	return sb.String()
+}
@@ -0,0 +1,291 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RateLimitPrimary   = "primary"
+	RateLimitSecondary = "secondary"
+)
+
+// GitHub asks clients to wait at least a minute after a secondary rate
+// limit that does not say how long to back off for.
+const defaultSecondaryWait = time.Minute
+
+// RateLimitError reports that GitHub refused a request because a rate
+// limit was exceeded.
+type RateLimitError struct {
+	Kind       string
+	Resource   string
+	Reset      time.Time
+	RetryAfter time.Duration
+	Message    string
+}
+
+func (e *RateLimitError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s rate limit exceeded", e.Kind)
+
+	if e.Resource != "" {
+		fmt.Fprintf(&sb, " for %s", e.Resource)
+	}
+
+	if wait := e.Wait(time.Now()); wait > 0 {
+		fmt.Fprintf(&sb, "; retry in %s", wait.Round(time.Second))
+	}
+
+	if !e.Reset.IsZero() {
+		fmt.Fprintf(&sb, " (resets at %s)", e.Reset.UTC().Format(time.RFC3339))
+	}
+
+	if e.Message != "" {
+		fmt.Fprintf(&sb, ": %s", e.Message)
+	}
+
+	return sb.String()
+}
+
+// Wait is how long to back off before retrying, as of now.
+func (e *RateLimitError) Wait(now time.Time) time.Duration {
+	if e.RetryAfter > 0 {
+		return e.RetryAfter
+	}
+
+	if !e.Reset.IsZero() {
+		// Reset has one-second resolution; pad so the retry lands after it.
+		return max(e.Reset.Sub(now)+time.Second, 0)
+	}
+
+	if e.Kind == RateLimitSecondary {
+		return defaultSecondaryWait
+	}
+
+	return 0
+}
+
+// RateLimitPolicy controls automatic backoff. Rate-limited calls are
+// retried up to MaxRetries times as long as each wait is at most MaxWait;
+// otherwise the RateLimitError is returned to the caller.
+type RateLimitPolicy struct {
+	MaxWait    time.Duration
+	MaxRetries int
+}
+
+var (
+	policyMu        sync.RWMutex
+	rateLimitPolicy = RateLimitPolicy{MaxWait: time.Minute, MaxRetries: 3}
+)
+
+func SetRateLimitPolicy(p RateLimitPolicy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+
+	rateLimitPolicy = p
+}
+
+func currentRateLimitPolicy() RateLimitPolicy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+
+	return rateLimitPolicy
+}
+
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// backoff waits out rlErr if the policy allows another attempt and
+// reports whether the caller should retry.
+func backoff(ctx context.Context, attempt int, rlErr *RateLimitError) bool {
+	policy := currentRateLimitPolicy()
+
+	if attempt >= policy.MaxRetries {
+		return false
+	}
+
+	// A primary limit with no known reset time has nothing to wait for.
+	if rlErr.Kind == RateLimitPrimary && rlErr.Reset.IsZero() && rlErr.RetryAfter == 0 {
+		return false
+	}
+
+	wait := rlErr.Wait(time.Now())
+	if wait > policy.MaxWait {
+		return false
+	}
+
+	return sleep(ctx, wait) == nil
+}
+
+// rateLimitFromStderr recognizes the rate-limit failures gh prints. gh
+// does not expose response headers here, so primary limits have their
+// reset time looked up separately.
+func rateLimitFromStderr(stderr string) *RateLimitError {
+	lower := strings.ToLower(stderr)
+
+	switch {
+	case strings.Contains(lower, "secondary rate limit"),
+		strings.Contains(lower, "abuse detection"),
+		strings.Contains(lower, "http 429"):
+		return &RateLimitError{Kind: RateLimitSecondary, Message: firstLine(stderr)}
+
+	case strings.Contains(lower, "api rate limit exceeded"),
+		strings.Contains(lower, "rate limit exceeded"):
+		return &RateLimitError{Kind: RateLimitPrimary, Message: firstLine(stderr)}
+	}
+
+	return nil
+}
+
+// doWithBackoff calls do until it returns a response that is not rate
+// limited or the policy gives up, in which case the last response is
+// returned alongside an *Error wrapping the RateLimitError.
+func doWithBackoff(ctx context.Context, op string, do func() (*Response, error)) (*Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := do()
+		if err != nil {
+			return nil, err
+		}
+
+		rlErr := rateLimitFromResponse(resp)
+		if rlErr == nil {
+			return resp, nil
+		}
+
+		if !backoff(ctx, attempt, rlErr) {
+			return resp, rateLimitedError(op, resp.StatusCode, rlErr)
+		}
+	}
+}
+
+func rateLimitFromResponse(resp *Response) *RateLimitError {
+	var payload struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	_ = json.Unmarshal(resp.Body, &payload)
+
+	rlErr := &RateLimitError{
+		Resource: resp.Header.Get("X-RateLimit-Resource"),
+		Message:  payload.Message,
+	}
+
+	// GraphQL reports an exhausted primary limit as a 200 with an error.
+	if resp.StatusCode == http.StatusOK {
+		for _, e := range payload.Errors {
+			if e.Type == "RATE_LIMITED" {
+				rlErr.Kind = RateLimitPrimary
+				rlErr.Message = e.Message
+				rlErr.Reset = resetHeader(resp)
+
+				return rlErr
+			}
+		}
+
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		rlErr.Kind = RateLimitSecondary
+		rlErr.RetryAfter = time.Duration(secs) * time.Second
+
+		return rlErr
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		rlErr.Kind = RateLimitPrimary
+		rlErr.Reset = resetHeader(resp)
+
+		return rlErr
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests ||
+		strings.Contains(strings.ToLower(payload.Message), "secondary rate limit") {
+		rlErr.Kind = RateLimitSecondary
+
+		return rlErr
+	}
+
+	return nil
+}
+
+func resetHeader(resp *Response) time.Time {
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(reset, 0)
+}
+
+// rateLimitResource guesses which rate limit bucket a gh invocation draws
+// from. gh's porcelain commands go through GraphQL.
+func rateLimitResource(args []string) string {
+	if len(args) < 2 || args[0] != "api" {
+		return "graphql"
+	}
+
+	endpoint := strings.TrimPrefix(args[1], "/")
+
+	switch {
+	case endpoint == "graphql":
+		return "graphql"
+	case strings.HasPrefix(endpoint, "search/code"):
+		return "code_search"
+	case strings.HasPrefix(endpoint, "search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// lookupReset fills in the reset time of a primary rate limit from the
+// rate_limit endpoint, which does not count against any limit.
+func lookupReset(ctx context.Context, rlErr *RateLimitError) {
+	stdout, _, err := execGH(ctx, nil, []string{"api", "rate_limit", "--method", "GET", "--jq", ".resources"})
+	if err != nil {
+		return
+	}
+
+	var resources map[string]struct {
+		Remaining int   `json:"remaining"`
+		Reset     int64 `json:"reset"`
+	}
+
+	if err := json.Unmarshal([]byte(stdout), &resources); err != nil {
+		return
+	}
+
+	if r, ok := resources[rlErr.Resource]; ok && r.Remaining == 0 {
+		rlErr.Reset = time.Unix(r.Reset, 0)
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,146 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+
+	prev := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	t.Cleanup(func() { sleep = prev })
+
+	return &waits
+}
+
+func TestRunBacksOffSecondaryRateLimit(t *testing.T) {
+	waits := stubSleep(t)
+	attempts := 0
+
+	t.Cleanup(SetRunner(RunnerFunc(func(ctx context.Context, stdin []byte, args []string) (*Invocation, error) {
+		attempts++
+		if attempts == 1 {
+			return &Invocation{
+				Args:     args,
+				Stderr:   "gh: You have exceeded a secondary rate limit. (HTTP 403)\n",
+				ExitCode: 1,
+			}, nil
+		}
+
+		return &Invocation{Args: args, Stdout: "ok"}, nil
+	})))
+
+	out, err := Run(context.Background(), "api", "search/code")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "ok" || attempts != 2 {
+		t.Errorf("got %q after %d attempts, want %q after 2", out, attempts, "ok")
+	}
+
+	if len(*waits) != 1 || (*waits)[0] != defaultSecondaryWait {
+		t.Errorf("waits = %v, want [%s]", *waits, defaultSecondaryWait)
+	}
+}
+
+func TestRunFailsWhenWaitExceedsPolicy(t *testing.T) {
+	stubSleep(t)
+	SetRateLimitPolicy(RateLimitPolicy{MaxWait: time.Second, MaxRetries: 3})
+	t.Cleanup(func() { SetRateLimitPolicy(RateLimitPolicy{MaxWait: time.Minute, MaxRetries: 3}) })
+
+	t.Cleanup(SetRunner(RunnerFunc(func(ctx context.Context, stdin []byte, args []string) (*Invocation, error) {
+		return &Invocation{
+			Args:     args,
+			Stderr:   "gh: You have exceeded a secondary rate limit. (HTTP 403)\n",
+			ExitCode: 1,
+		}, nil
+	})))
+
+	_, err := Run(context.Background(), "api", "search/code")
+
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("err = %v, want RateLimitError", err)
+	}
+
+	if rlErr.Kind != RateLimitSecondary || rlErr.Resource != "code_search" {
+		t.Errorf("got %s limit on %q, want secondary on code_search", rlErr.Kind, rlErr.Resource)
+	}
+}
+
+func TestRateLimitFromResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		kind       string
+		retryAfter time.Duration
+		reset      time.Time
+	}{
+		{
+			name:   "primary",
+			status: http.StatusForbidden,
+			header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {"1791043200"},
+			},
+			body:  `{"message":"API rate limit exceeded"}`,
+			kind:  RateLimitPrimary,
+			reset: time.Unix(1791043200, 0),
+		},
+		{
+			name:       "retry after",
+			status:     http.StatusTooManyRequests,
+			header:     http.Header{"Retry-After": {"30"}},
+			kind:       RateLimitSecondary,
+			retryAfter: 30 * time.Second,
+		},
+		{
+			name:   "graphql",
+			status: http.StatusOK,
+			header: http.Header{"X-Ratelimit-Reset": {"1791043200"}},
+			body:   `{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`,
+			kind:   RateLimitPrimary,
+			reset:  time.Unix(1791043200, 0),
+		},
+		{
+			name:   "permission denied",
+			status: http.StatusForbidden,
+			header: http.Header{"X-Ratelimit-Remaining": {"4999"}},
+			body:   `{"message":"Resource not accessible by integration"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rlErr := rateLimitFromResponse(&Response{StatusCode: tt.status, Header: tt.header, Body: []byte(tt.body)})
+
+			if tt.kind == "" {
+				if rlErr != nil {
+					t.Fatalf("got %v, want no rate limit", rlErr)
+				}
+
+				return
+			}
+
+			if rlErr == nil {
+				t.Fatal("rate limit not detected")
+			}
+
+			if rlErr.Kind != tt.kind || rlErr.RetryAfter != tt.retryAfter || !rlErr.Reset.Equal(tt.reset) {
+				t.Errorf("got %+v", rlErr)
+			}
+		})
+	}
+}
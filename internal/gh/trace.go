@@ -0,0 +1,49 @@
+package gh
+
+import (
+	"context"
+	"sync"
+)
+
+// Trace collects the gh executions made under a context, with their
+// input but without their output. API calls HTTPClient makes are collected as the `gh api`
+// invocations that would have made them.
+type Trace struct {
+	mu          sync.Mutex
+	invocations []Invocation
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context under which every gh execution and API call
+// is added to t.
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+func (t *Trace) add(inv *Invocation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.invocations = append(t.invocations, Invocation{
+		Args:     inv.Args,
+		Host:     inv.Host,
+		Stdin:    inv.Stdin,
+		ExitCode: inv.ExitCode,
+	})
+}
+
+// Invocations returns the recorded executions in order. Only Args, Host,
+// Stdin and ExitCode are set.
+func (t *Trace) Invocations() []Invocation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Invocation(nil), t.invocations...)
+}
+
+func traceInvocation(ctx context.Context, inv *Invocation) {
+	if t, ok := ctx.Value(traceKey{}).(*Trace); ok {
+		t.add(inv)
+	}
+}
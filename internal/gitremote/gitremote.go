@@ -0,0 +1,148 @@
+// Package gitremote resolves the GitHub repository of a local checkout.
+package gitremote
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+type Repo struct {
+	Host  string
+	Owner string
+	Name  string
+}
+
+func (r Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+type remote struct {
+	name       string
+	url        string
+	ghResolved string
+}
+
+// DefaultRepo picks the repository gh would use in dir: the remote marked
+// by `gh repo set-default` if there is one, otherwise the first of the
+// upstream, github and origin remotes, otherwise any remote.
+func DefaultRepo(ctx context.Context, dir string) (Repo, error) {
+	cmd := exec.CommandContext(ctx, "git", "config", "--get-regexp", `^remote\..*\.(url|gh-resolved)$`)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return Repo{}, fmt.Errorf("reading git remotes: %w", err)
+	}
+
+	return pickRepo(parseRemotes(string(out)))
+}
+
+func parseRemotes(config string) []remote {
+	var remotes []remote
+
+	index := func(name string) int {
+		i := slices.IndexFunc(remotes, func(r remote) bool { return r.name == name })
+		if i < 0 {
+			remotes = append(remotes, remote{name: name})
+			i = len(remotes) - 1
+		}
+
+		return i
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(config))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+
+		rest := strings.TrimPrefix(key, "remote.")
+		dot := strings.LastIndex(rest, ".")
+		if dot < 0 {
+			continue
+		}
+
+		name, field := rest[:dot], rest[dot+1:]
+
+		switch field {
+		case "url":
+			remotes[index(name)].url = value
+		case "gh-resolved":
+			remotes[index(name)].ghResolved = value
+		}
+	}
+
+	return remotes
+}
+
+func pickRepo(remotes []remote) (Repo, error) {
+	for _, r := range remotes {
+		if r.ghResolved == "" {
+			continue
+		}
+
+		if r.ghResolved != "base" {
+			if owner, name, ok := strings.Cut(r.ghResolved, "/"); ok {
+				repo, _ := ParseURL(r.url)
+				return Repo{Host: repo.Host, Owner: owner, Name: name}, nil
+			}
+		}
+
+		return ParseURL(r.url)
+	}
+
+	for _, preferred := range []string{"upstream", "github", "origin"} {
+		i := slices.IndexFunc(remotes, func(r remote) bool { return r.name == preferred })
+		if i < 0 {
+			continue
+		}
+
+		if repo, err := ParseURL(remotes[i].url); err == nil {
+			return repo, nil
+		}
+	}
+
+	for _, r := range remotes {
+		if repo, err := ParseURL(r.url); err == nil {
+			return repo, nil
+		}
+	}
+
+	return Repo{}, errors.New("no GitHub remote found")
+}
+
+// ParseURL extracts host, owner and name from https, ssh and scp-style
+// git remote URLs.
+func ParseURL(remoteURL string) (Repo, error) {
+	var host, path string
+
+	if u, err := url.Parse(remoteURL); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remoteURL, ":"); ok && !strings.Contains(at, "/") {
+		// scp-like syntax: [user@]host:owner/repo.git
+		_, h, found := strings.Cut(at, "@")
+		if !found {
+			h = at
+		}
+
+		host, path = h, rest
+	} else {
+		return Repo{}, fmt.Errorf("unrecognized remote URL %q", remoteURL)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	owner, name, ok := strings.Cut(path, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return Repo{}, fmt.Errorf("remote URL %q does not name OWNER/REPO", remoteURL)
+	}
+
+	return Repo{Host: strings.ToLower(host), Owner: owner, Name: name}, nil
+}
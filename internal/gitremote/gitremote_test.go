@@ -0,0 +1,80 @@
+package gitremote
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Repo
+	}{
+		{"https://github.com/octo/hello.git", Repo{"github.com", "octo", "hello"}},
+		{"https://github.com/octo/hello", Repo{"github.com", "octo", "hello"}},
+		{"git@github.com:octo/hello.git", Repo{"github.com", "octo", "hello"}},
+		{"ssh://git@ghe.example.com:2222/octo/hello.git", Repo{"ghe.example.com", "octo", "hello"}},
+		{"github.com:octo/hello", Repo{"github.com", "octo", "hello"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseURL(%q): %v", tt.url, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"/tmp/local.git", "https://github.com/octo"} {
+		if _, err := ParseURL(bad); err == nil {
+			t.Errorf("ParseURL(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestPickRepo(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name: "prefers upstream over origin",
+			config: "remote.origin.url git@github.com:me/hello.git\n" +
+				"remote.upstream.url https://github.com/octo/hello.git\n",
+			want: "octo/hello",
+		},
+		{
+			name: "honours gh repo set-default",
+			config: "remote.origin.url git@github.com:me/hello.git\n" +
+				"remote.origin.gh-resolved base\n" +
+				"remote.upstream.url https://github.com/octo/hello.git\n",
+			want: "me/hello",
+		},
+		{
+			name: "explicit resolved repository",
+			config: "remote.origin.url git@github.com:me/hello.git\n" +
+				"remote.origin.gh-resolved octo/fork-target\n",
+			want: "octo/fork-target",
+		},
+		{
+			name:   "falls back to any remote",
+			config: "remote.my.mirror.url https://github.com/octo/mirror.git\n",
+			want:   "octo/mirror",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := pickRepo(parseRemotes(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if repo.FullName() != tt.want {
+				t.Errorf("got %s, want %s", repo.FullName(), tt.want)
+			}
+		})
+	}
+}
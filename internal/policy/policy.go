@@ -0,0 +1,226 @@
+// Package policy decides which repositories the server may touch.
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// DefaultHost is the host patterns without one apply to when New is given
+// no default host.
+const DefaultHost = "github.com"
+
+// pattern is an OWNER/REPO glob on one host.
+type pattern struct {
+	host, owner, repo string
+}
+
+func (p pattern) matchesOwner(host, owner string) bool {
+	if host != p.host {
+		return false
+	}
+
+	matched, _ := path.Match(p.owner, owner)
+
+	return matched
+}
+
+func (p pattern) matches(host, owner, repo string) bool {
+	if !p.matchesOwner(host, owner) {
+		return false
+	}
+
+	matched, _ := path.Match(p.repo, repo)
+
+	return matched
+}
+
+// Policy holds repository patterns in path.Match syntax, compared
+// case-insensitively, with hosts compared as gh.NormalizeHost leaves them. A pattern is OWNER/REPO, which applies to the
+// default host, or HOST/OWNER/REPO. A repository is allowed when it
+// matches no Deny pattern and, if there are Allow patterns, at least one
+// of them.
+type Policy struct {
+	defaultHost string
+	allow       []pattern
+	deny        []pattern
+}
+
+// New parses allow and deny patterns. Patterns without a host apply to
+// defaultHost, or to DefaultHost when it is empty.
+func New(allow, deny []string, defaultHost string) (*Policy, error) {
+	if defaultHost = gh.NormalizeHost(defaultHost); defaultHost == "" {
+		defaultHost = DefaultHost
+	}
+
+	p := &Policy{defaultHost: defaultHost}
+
+	for _, s := range allow {
+		parsed, err := parse(s, defaultHost)
+		if err != nil {
+			return nil, err
+		}
+
+		p.allow = append(p.allow, parsed)
+	}
+
+	for _, s := range deny {
+		parsed, err := parse(s, defaultHost)
+		if err != nil {
+			return nil, err
+		}
+
+		p.deny = append(p.deny, parsed)
+	}
+
+	return p, nil
+}
+
+func parse(s, defaultHost string) (pattern, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	var p pattern
+
+	switch parts := strings.Split(s, "/"); len(parts) {
+	case 2:
+		p = pattern{host: defaultHost, owner: parts[0], repo: parts[1]}
+	case 3:
+		p = pattern{host: gh.NormalizeHost(parts[0]), owner: parts[1], repo: parts[2]}
+	default:
+		return p, fmt.Errorf("invalid repository pattern %q: want OWNER/REPO or HOST/OWNER/REPO", s)
+	}
+
+	if p.host == "" || p.owner == "" || p.repo == "" {
+		return p, fmt.Errorf("invalid repository pattern %q: want OWNER/REPO or HOST/OWNER/REPO", s)
+	}
+
+	for _, part := range []string{p.owner, p.repo} {
+		if _, err := path.Match(part, ""); err != nil {
+			return p, fmt.Errorf("invalid repository pattern %q: %w", s, err)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Policy) Active() bool {
+	return p != nil && (len(p.allow) > 0 || len(p.deny) > 0)
+}
+
+// Restricted reports whether only repositories matching an Allow pattern
+// may be touched, so a call that cannot say which repositories it reaches
+// must be refused.
+func (p *Policy) Restricted() bool {
+	return p != nil && len(p.allow) > 0
+}
+
+// CheckRepo decides a call on repo, OWNER/REPO, on host. An empty host is
+// the default host.
+func (p *Policy) CheckRepo(host, repo string) error {
+	if !p.Active() {
+		return nil
+	}
+
+	host = p.host(host)
+
+	owner, name, ok := strings.Cut(strings.ToLower(repo), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("policy: cannot check %q: want OWNER/REPO", repo)
+	}
+
+	for _, d := range p.deny {
+		if d.matches(host, owner, name) {
+			return fmt.Errorf("policy: repository %s is denied", p.label(host, repo))
+		}
+	}
+
+	if len(p.allow) == 0 {
+		return nil
+	}
+
+	for _, a := range p.allow {
+		if a.matches(host, owner, name) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("policy: repository %s is not allowed", p.label(host, repo))
+}
+
+// CheckOwner decides owner-wide operations such as listing an owner's
+// repositories or searching an organization. Their results cover every
+// repository of the owner, so with Allow patterns the owner needs one
+// allowing all of its repositories, OWNER/*; one allowing only some, such
+// as OWNER/oss-*, is not enough. Likewise any Deny pattern for the owner
+// refuses them: OWNER/* denies the owner outright, and one denying only
+// some repositories, such as OWNER/secret-*, would otherwise leak those
+// through the owner-wide results.
+func (p *Policy) CheckOwner(host, owner string) error {
+	if !p.Active() {
+		return nil
+	}
+
+	host = p.host(host)
+	name := strings.ToLower(owner)
+
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("policy: cannot check owner %q", owner)
+	}
+
+	for _, d := range p.deny {
+		if !d.matchesOwner(host, name) {
+			continue
+		}
+
+		if d.repo == "*" {
+			return fmt.Errorf("policy: owner %s is denied", p.label(host, owner))
+		}
+
+		return fmt.Errorf("policy: owner-wide calls on %s are refused because deny pattern %s covers some of its repositories", p.label(host, owner), p.label(d.host, d.owner+"/"+d.repo))
+	}
+
+	if len(p.allow) == 0 {
+		return nil
+	}
+
+	partly := false
+
+	for _, a := range p.allow {
+		if !a.matchesOwner(host, name) {
+			continue
+		}
+
+		if a.repo == "*" {
+			return nil
+		}
+
+		partly = true
+	}
+
+	if partly {
+		return fmt.Errorf("policy: only some repositories of owner %s are allowed; owner-wide calls need %s/* in the allow list", p.label(host, owner), owner)
+	}
+
+	return fmt.Errorf("policy: owner %s is not allowed", p.label(host, owner))
+}
+
+func (p *Policy) host(host string) string {
+	if host == "" {
+		return p.defaultHost
+	}
+
+	return gh.NormalizeHost(host)
+}
+
+// label names a repository or owner in errors, with its host when that is
+// not the default.
+func (p *Policy) label(host, name string) string {
+	if host == p.defaultHost {
+		return name
+	}
+
+	return host + "/" + name
+}
@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckRepo(t *testing.T) {
+	p, err := New([]string{"octo/*", "friends/shared-*", "ghe.corp/tools/*"}, []string{"octo/secret-*"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host    string
+		repo    string
+		allowed bool
+	}{
+		{"", "octo/hello", true},
+		{"", "Octo/Hello", true},
+		{"github.com", "octo/hello", true},
+		{"", "octo/secret-plans", false},
+		{"", "friends/shared-lib", true},
+		{"", "friends/private", false},
+		{"", "customer/app", false},
+		{"", "octo", false},
+		{"", "octo/hello/extra", false},
+		{"ghe.corp", "octo/hello", false},
+		{"GHE.corp", "tools/ci", true},
+		{"api.ghe.corp:443", "tools/ci", true},
+		{"api.github.com", "octo/secret-plans", false},
+		{"www.github.com.", "customer/app", false},
+		{"", "tools/ci", false},
+	}
+
+	for _, tt := range tests {
+		if err := p.CheckRepo(tt.host, tt.repo); (err == nil) != tt.allowed {
+			t.Errorf("CheckRepo(%q, %q) = %v, want allowed=%v", tt.host, tt.repo, err, tt.allowed)
+		}
+	}
+}
+
+func TestPatternsFollowDefaultHost(t *testing.T) {
+	p, err := New([]string{"octo/*"}, nil, "ghe.corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.CheckRepo("", "octo/hello"); err != nil {
+		t.Errorf("default host: %v", err)
+	}
+
+	if err := p.CheckRepo("github.com", "octo/hello"); err == nil {
+		t.Error("pattern for ghe.corp allowed github.com")
+	}
+}
+
+func TestCheckOwner(t *testing.T) {
+	p, err := New([]string{"octo/*", "friends/shared-*"}, []string{"octo-archive/*"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for owner, allowed := range map[string]bool{
+		"octo":         true,
+		"friends":      false,
+		"customer":     false,
+		"octo-archive": false,
+	} {
+		if err := p.CheckOwner("", owner); (err == nil) != allowed {
+			t.Errorf("CheckOwner(%q) = %v, want allowed=%v", owner, err, allowed)
+		}
+	}
+
+	if err := p.CheckOwner("ghe.corp", "octo"); err == nil {
+		t.Error("owner allowed on github.com was allowed on ghe.corp")
+	}
+}
+
+func TestCheckOwnerRefusesPartlyDeniedOwner(t *testing.T) {
+	for _, allow := range [][]string{nil, {"acme/*"}} {
+		p, err := New(allow, []string{"acme/secret-*", "ghe.corp/tools/internal"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = p.CheckOwner("", "Acme")
+		if err == nil || !strings.Contains(err.Error(), "acme/secret-*") {
+			t.Errorf("allow %q: CheckOwner(acme) = %v, want refusal naming acme/secret-*", allow, err)
+		}
+
+		if err := p.CheckRepo("", "acme/public"); err != nil {
+			t.Errorf("allow %q: CheckRepo(acme/public) = %v", allow, err)
+		}
+
+		err = p.CheckOwner("ghe.corp", "tools")
+		if err == nil || !strings.Contains(err.Error(), "ghe.corp/tools/internal") {
+			t.Errorf("allow %q: CheckOwner(ghe.corp, tools) = %v, want refusal naming ghe.corp/tools/internal", allow, err)
+		}
+	}
+
+	p, err := New(nil, []string{"acme/secret-*"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.CheckOwner("", "octo"); err != nil {
+		t.Errorf("owner with no deny pattern refused: %v", err)
+	}
+}
+
+func TestInactivePolicyAllowsEverything(t *testing.T) {
+	var p *Policy
+
+	if err := p.CheckRepo("", "anyone/anything"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewRejectsMalformedPatterns(t *testing.T) {
+	for _, pattern := range []string{"octo", "host/octo/a/b", "octo/[", "", "/octo/a", "octo/"} {
+		if _, err := New([]string{pattern}, nil, ""); err == nil {
+			t.Errorf("New(%q) succeeded", pattern)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Package redact masks credentials in text before it leaves the server.
+package redact
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a named regular expression. When it has a capture group
+// only the first group is masked, so surrounding context such as a
+// variable name survives.
+type Pattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+}
+
+// DefaultPatterns cover GitHub tokens and common cloud and SaaS
+// credentials.
+var DefaultPatterns = []Pattern{
+	{"github_token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"github_pat", regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`)},
+	{"aws_access_key_id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_access_key", regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"azure_account_key", regexp.MustCompile(`(?i)AccountKey=([A-Za-z0-9+/=]{40,})`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"authorization", regexp.MustCompile(`(?i)\bauthorization:\s*(?:bearer|token|basic)\s+([^\s"']+)`)},
+}
+
+// Redactor replaces every match of its patterns, and optionally every
+// high-entropy word, with a [REDACTED:name] marker.
+type Redactor struct {
+	Patterns []Pattern
+
+	// MinEntropy enables masking of words of at least minEntropyLength
+	// characters whose Shannon entropy is at least MinEntropy bits per
+	// character. Zero disables it.
+	MinEntropy float64
+}
+
+// DefaultMinEntropy sits above hex (at most 4 bits per character), so
+// commit SHAs and digests are left alone, and below typical base64 and
+// base62 secrets.
+const DefaultMinEntropy = 4.5
+
+const minEntropyLength = 32
+
+func New(extra []Pattern) *Redactor {
+	return &Redactor{
+		Patterns:   append(append([]Pattern(nil), DefaultPatterns...), extra...),
+		MinEntropy: DefaultMinEntropy,
+	}
+}
+
+var labelPattern = regexp.MustCompile(`^\w+$`)
+
+// LoadPatterns reads one regular expression per line from path. Blank
+// lines and lines starting with # are skipped; a line may be prefixed
+// with "name:" followed by whitespace to label its matches.
+func LoadPatterns(path string) ([]Pattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening redaction patterns: %w", err)
+	}
+	defer f.Close()
+
+	var patterns []Pattern
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name := "custom"
+		if label, expr, ok := strings.Cut(text, ": "); ok && labelPattern.MatchString(label) {
+			name, text = label, strings.TrimSpace(expr)
+		}
+
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		patterns = append(patterns, Pattern{Name: name, Regexp: re})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading redaction patterns: %w", err)
+	}
+
+	return patterns, nil
+}
+
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+
+	for _, p := range r.Patterns {
+		s = replace(p, s)
+	}
+
+	if r.MinEntropy > 0 {
+		s = r.redactHighEntropy(s)
+	}
+
+	return s
+}
+
+// redactHighEntropy masks high-entropy words other than the values of
+// identifierKeys and GitHub's own node IDs and cursors.
+func (r *Redactor) redactHighEntropy(s string) string {
+	var sb strings.Builder
+
+	last := 0
+	for _, m := range highEntropyPattern.FindAllStringIndex(s, -1) {
+		start, end := m[0], m[1]
+		word := s[start:end]
+
+		if isGitHubIdentifier(word) || identifierKeyPattern.MatchString(s[max(0, start-identifierKeyContext):start]) {
+			continue
+		}
+
+		sb.WriteString(s[last:start])
+		sb.WriteString(r.redactWord(word))
+		last = end
+	}
+
+	sb.WriteString(s[last:])
+
+	return sb.String()
+}
+
+// identifierKeyPattern matches the end of the text before a JSON string
+// value whose key holds an identifier or pagination cursor, which are
+// random-looking by design but not secret.
+var identifierKeyPattern = regexp.MustCompile(`"(?:id|node_id|nodeId|cursor|startCursor|endCursor|after|before)"\s*:\s*"$`)
+
+// identifierKeyContext is how far back identifierKeyPattern looks.
+const identifierKeyContext = 32
+
+var (
+	// nodeIDPattern matches GitHub's node IDs: a type prefix such as
+	// PRRT or I, then base64url-encoded MessagePack, which starts with
+	// kw or kg.
+	nodeIDPattern = regexp.MustCompile(`^[A-Z][A-Za-z]{0,7}_k[gw][A-Za-z0-9_\-]+$`)
+
+	// legacyNodeIDPattern matches what a legacy node ID such as
+	// MDExOlB1bGxSZXF1ZXN0MQ== decodes to: a type name length, a colon,
+	// the type name and a database ID.
+	legacyNodeIDPattern = regexp.MustCompile(`^[0-9]+:[A-Za-z]+[0-9]+$`)
+)
+
+// isGitHubIdentifier reports whether word is a GitHub node ID or a
+// GraphQL pagination cursor, which decodes to cursor:....
+func isGitHubIdentifier(word string) bool {
+	if nodeIDPattern.MatchString(word) {
+		return true
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(word)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(word)
+	}
+
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(string(decoded), "cursor:") || legacyNodeIDPattern.Match(decoded)
+}
+
+// redactWord masks a high-entropy candidate. A run of path segments, such
+// as a blob URL's owner/repo/blob/SHA/path, mixes enough characters to
+// look random as a whole, so words with slashes are judged a segment at a
+// time. Only + and = mark a word as base64, which is checked whole since
+// its slashes are part of the encoding.
+func (r *Redactor) redactWord(word string) string {
+	if !strings.Contains(word, "/") || strings.ContainsAny(word, "+=") {
+		if isHighEntropy(word, r.MinEntropy) {
+			return marker("high_entropy")
+		}
+
+		return word
+	}
+
+	segments := strings.Split(word, "/")
+	for i, segment := range segments {
+		if isHighEntropy(segment, r.MinEntropy) {
+			segments[i] = marker("high_entropy")
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func replace(p Pattern, s string) string {
+	if p.Regexp.NumSubexp() == 0 {
+		return p.Regexp.ReplaceAllLiteralString(s, marker(p.Name))
+	}
+
+	var sb strings.Builder
+
+	last := 0
+	for _, m := range p.Regexp.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2], m[3]
+		if start < 0 {
+			continue
+		}
+
+		sb.WriteString(s[last:start])
+		sb.WriteString(marker(p.Name))
+		last = end
+	}
+
+	sb.WriteString(s[last:])
+
+	return sb.String()
+}
+
+func marker(name string) string {
+	return "[REDACTED:" + name + "]"
+}
+
+var highEntropyPattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{32,}`)
+
+func isHighEntropy(word string, threshold float64) bool {
+	if len(word) < minEntropyLength {
+		return false
+	}
+
+	if !strings.ContainsAny(word, "0123456789") || strings.IndexFunc(word, isLetter) < 0 {
+		return false
+	}
+
+	return entropy(word) >= threshold
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var h float64
+
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+
+	return h
+}
@@ -0,0 +1,51 @@
+package tools
+
+// ToolAnnotations are the MCP behaviour hints for a tool. Clients use them
+// to auto-approve reads and prompt before writes.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint"`
+	DestructiveHint bool   `json:"destructiveHint"`
+	IdempotentHint  bool   `json:"idempotentHint"`
+	OpenWorldHint   bool   `json:"openWorldHint"`
+}
+
+// destructive marks a mutating tool whose writes can delete or overwrite
+// data on GitHub.
+func destructive(t *tool) {
+	t.mutating = true
+	t.destructive = true
+}
+
+// idempotent marks a mutating tool whose writes can be repeated with no
+// further effect, such as setting a state the target may already have.
+func idempotent(t *tool) {
+	t.mutating = true
+	t.idempotent = true
+}
+
+func (t tool) annotations() ToolAnnotations {
+	// Every tool talks to GitHub, an open world of data this server does
+	// not control. Reads are idempotent; writes are assumed not to be,
+	// since repeating one creates another issue or comment, unless marked
+	// idempotent.
+	return ToolAnnotations{
+		ReadOnlyHint:    !t.mutating,
+		DestructiveHint: t.destructive,
+		IdempotentHint:  !t.mutating || t.idempotent,
+		OpenWorldHint:   true,
+	}
+}
+
+// Annotations returns the annotations of every tool, keyed by name.
+func Annotations() map[string]ToolAnnotations {
+	r := newToolRegistry(Options{})
+	registerTools(r)
+
+	annotations := make(map[string]ToolAnnotations, len(r.tools))
+	for _, t := range r.tools {
+		annotations[t.name] = t.annotations()
+	}
+
+	return annotations
+}
@@ -1,16 +1,21 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/amarbel-llc/go-lib-mcp/protocol"
-	"github.com/amarbel-llc/go-lib-mcp/server"
 	"github.com/friedenberg/get-hubbed/internal/gh"
 )
 
-func registerAPITools(r *server.ToolRegistry) {
+func registerAPITools(r *toolRegistry) {
 	r.Register(
 		"api_get",
 		"Make an authenticated GET request to the GitHub REST API",
@@ -34,6 +39,10 @@ func registerAPITools(r *server.ToolRegistry) {
 				"paginate": {
 					"type": "boolean",
 					"description": "Auto-paginate results"
+				},
+				"jq": {
+					"type": "string",
+					"description": "jq expression gh applies to the JSON output before returning it, e.g. '.[] | {number, title}'"
 				}
 			},
 			"required": ["endpoint"]
@@ -59,6 +68,10 @@ func registerAPITools(r *server.ToolRegistry) {
 				"paginate": {
 					"type": "boolean",
 					"description": "Auto-paginate results (requires endCursor/pageInfo in query)"
+				},
+				"jq": {
+					"type": "string",
+					"description": "jq expression gh applies to the JSON output before returning it, e.g. '.data.repository.issues.nodes[].title'"
 				}
 			},
 			"required": ["query"]
@@ -85,6 +98,7 @@ func registerAPITools(r *server.ToolRegistry) {
 			"required": ["query"]
 		}`),
 		handleGraphQLMutation,
+		destructive,
 	)
 }
 
@@ -94,62 +108,164 @@ func handleAPIGet(ctx context.Context, args json.RawMessage) (*protocol.ToolCall
 		Params   map[string]string `json:"params"`
 		Headers  []string          `json:"headers"`
 		Paginate bool              `json:"paginate"`
+		JQ       string            `json:"jq"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	if err := requireGET(params.Endpoint, params.Headers); err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
-	ghArgs := []string{"api", params.Endpoint, "--method", "GET"}
+	// gh applies jq expressions; the API clients return bodies as they are.
+	if params.JQ != "" {
+		ghArgs := []string{"api", params.Endpoint, "--method", "GET"}
+
+		for _, k := range slices.Sorted(maps.Keys(params.Params)) {
+			ghArgs = append(ghArgs, "-f", fmt.Sprintf("%s=%s", k, params.Params[k]))
+		}
+
+		for _, h := range params.Headers {
+			ghArgs = append(ghArgs, "-H", h)
+		}
+
+		if params.Paginate {
+			ghArgs = append(ghArgs, "--paginate")
+		}
+
+		ghArgs = append(ghArgs, "--jq", params.JQ)
+
+		out, err := gh.Run(ctx, ghArgs...)
+		if err != nil {
+			return ghErrorResult("gh api", err), nil
+		}
+
+		return &protocol.ToolCallResult{
+			Content: []protocol.ContentBlock{
+				protocol.TextContent(out),
+			},
+		}, nil
+	}
+
+	req := gh.Request{
+		Method:  http.MethodGet,
+		Path:    params.Endpoint,
+		Params:  make(url.Values),
+		Headers: make(http.Header),
+	}
 
 	for k, v := range params.Params {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("%s=%s", k, v))
+		req.Params.Set(k, v)
 	}
 
 	for _, h := range params.Headers {
-		ghArgs = append(ghArgs, "-H", h)
+		name, value, _ := strings.Cut(h, ":")
+		req.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
 	}
 
+	var pages [][]byte
+
 	if params.Paginate {
-		ghArgs = append(ghArgs, "--paginate")
-	}
+		err := gh.Paginate(ctx, gh.Default(), req, func(resp *gh.Response) error {
+			pages = append(pages, resp.Body)
+			return nil
+		})
+		if err != nil {
+			return ghErrorResult("gh api", err), nil
+		}
+	} else {
+		resp, err := gh.Default().REST(ctx, req)
+		if err != nil {
+			return ghErrorResult("gh api", err), nil
+		}
 
-	out, err := gh.Run(ctx, ghArgs...)
-	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api: %v", err)), nil
+		pages = append(pages, resp.Body)
 	}
 
 	return &protocol.ToolCallResult{
 		Content: []protocol.ContentBlock{
-			protocol.TextContent(out),
+			protocol.TextContent(joinPages(pages)),
 		},
 	}, nil
 }
 
+// joinPages joins the pages of a paginated response the way gh
+// --paginate does: pages holding JSON arrays are merged into one array,
+// anything else is concatenated.
+func joinPages(pages [][]byte) string {
+	if len(pages) == 1 {
+		return string(pages[0])
+	}
+
+	var merged []json.RawMessage
+
+	for _, page := range pages {
+		var items []json.RawMessage
+		if err := json.Unmarshal(page, &items); err != nil {
+			return string(bytes.Join(pages, []byte("\n")))
+		}
+
+		merged = append(merged, items...)
+	}
+
+	out, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return string(bytes.Join(pages, []byte("\n")))
+	}
+
+	return string(out)
+}
+
 func handleGraphQLQuery(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
 		Query     string                 `json:"query"`
 		Variables map[string]interface{} `json:"variables"`
 		Paginate  bool                   `json:"paginate"`
+		JQ        string                 `json:"jq"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	if isMutation(params.Query) {
+		return errorResult(gh.KindValidation, "graphql_query only runs queries; use graphql_mutation for mutations"), nil
+	}
+
+	// gh follows endCursor for --paginate and applies jq expressions; a
+	// plain query goes through the API client.
+	if !params.Paginate && params.JQ == "" {
+		resp, err := gh.Default().GraphQL(ctx, params.Query, params.Variables)
+		if err != nil {
+			return ghErrorResult("gh api graphql", err), nil
+		}
+
+		return &protocol.ToolCallResult{
+			Content: []protocol.ContentBlock{
+				protocol.TextContent(string(resp.Body)),
+			},
+		}, nil
 	}
 
 	ghArgs := []string{"api", "graphql", "-f", fmt.Sprintf("query=%s", params.Query)}
 
-	for k, v := range params.Variables {
-		ghArgs = append(ghArgs, "-F", fmt.Sprintf("%s=%v", k, v))
+	for _, k := range slices.Sorted(maps.Keys(params.Variables)) {
+		ghArgs = append(ghArgs, "-F", fmt.Sprintf("%s=%v", k, params.Variables[k]))
 	}
 
 	if params.Paginate {
 		ghArgs = append(ghArgs, "--paginate")
 	}
 
+	if params.JQ != "" {
+		ghArgs = append(ghArgs, "--jq", params.JQ)
+	}
+
 	out, err := gh.Run(ctx, ghArgs...)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api graphql: %v", err)), nil
+		return ghErrorResult("gh api graphql", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -166,23 +282,320 @@ func handleGraphQLMutation(ctx context.Context, args json.RawMessage) (*protocol
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
-	ghArgs := []string{"api", "graphql", "-f", fmt.Sprintf("query=%s", params.Query)}
-
-	for k, v := range params.Variables {
-		ghArgs = append(ghArgs, "-F", fmt.Sprintf("%s=%v", k, v))
-	}
-
-	out, err := gh.Run(ctx, ghArgs...)
+	resp, err := gh.Default().GraphQL(ctx, params.Query, params.Variables)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api graphql mutation: %v", err)), nil
+		return ghErrorResult("gh api graphql mutation", err), nil
 	}
 
 	return &protocol.ToolCallResult{
 		Content: []protocol.ContentBlock{
-			protocol.TextContent(out),
+			protocol.TextContent(string(resp.Body)),
 		},
 	}, nil
 }
+
+// requireGET rejects api_get arguments that would make gh send anything
+// other than a GET: a method override header, or an endpoint gh would
+// parse as a flag. The schema has no method argument, so validation
+// already turns away an explicit method.
+func requireGET(endpoint string, headers []string) error {
+	if strings.HasPrefix(endpoint, "-") {
+		return fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+
+	for _, h := range headers {
+		name, value, _ := strings.Cut(h, ":")
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "x-http-method-override", "x-http-method", "x-method-override":
+			if !strings.EqualFold(strings.TrimSpace(value), http.MethodGet) {
+				return fmt.Errorf("api_get only makes GET requests, got header %q", h)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isMutation reports whether a GraphQL document defines a mutation
+// operation, skipping comments, strings and selection sets.
+func isMutation(query string) bool {
+	depth := 0
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case c == '#':
+			i = skipGraphQLComment(query, i)
+
+		case c == '"':
+			i = skipGraphQLString(query, i)
+
+		case c == '{' || c == '(':
+			depth++
+
+		case c == '}' || c == ')':
+			depth--
+
+		case depth == 0 && isNameStart(c):
+			start := i
+			for i < len(query) && isNameChar(query[i]) {
+				i++
+			}
+
+			if query[start:i] == "mutation" {
+				return true
+			}
+
+			i--
+		}
+	}
+
+	return false
+}
+
+// graphQLField is a field selected at the top level of an operation, with
+// the text between the parentheses of its arguments.
+type graphQLField struct {
+	name, args string
+}
+
+// graphQLRootFields returns the fields an operation selects at its top
+// level, past aliases and directives, and whether it also spreads a
+// fragment there, whose fields it cannot see.
+func graphQLRootFields(query string) (fields []graphQLField, spread bool) {
+	var (
+		braces, parens int
+		definition     string
+		inFragment     bool
+	)
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case c == '#':
+			i = skipGraphQLComment(query, i)
+
+		case c == '"':
+			i = skipGraphQLString(query, i)
+
+		case c == '(':
+			parens++
+
+		case c == ')':
+			parens--
+
+		case parens > 0:
+			// Variable definitions and arguments select nothing.
+
+		case c == '{':
+			if braces == 0 {
+				inFragment = definition == "fragment"
+			}
+
+			braces++
+
+		case c == '}':
+			if braces--; braces == 0 {
+				definition = ""
+			}
+
+		case braces == 0 && isNameStart(c):
+			end := scanGraphQLName(query, i)
+			if definition == "" {
+				definition = query[i:end]
+			}
+
+			i = end - 1
+
+		case braces != 1 || inFragment:
+
+		case c == '.' && strings.HasPrefix(query[i:], "..."):
+			spread = true
+
+			// Skip the fragment name, or the type condition of an inline
+			// fragment.
+			start := skipGraphQLSpace(query, i+3)
+			end := scanGraphQLName(query, start)
+
+			if query[start:end] == "on" {
+				end = scanGraphQLName(query, skipGraphQLSpace(query, end))
+			}
+
+			i = end - 1
+
+		case c == '@':
+			// A directive: its name and arguments select nothing.
+			i = scanGraphQLName(query, i+1) - 1
+
+		case isNameStart(c):
+			end := scanGraphQLName(query, i)
+			name := query[i:end]
+
+			next := skipGraphQLSpace(query, end)
+			if next < len(query) && query[next] == ':' {
+				// An alias; the field follows it.
+				start := skipGraphQLSpace(query, next+1)
+				end = scanGraphQLName(query, start)
+				name = query[start:end]
+			}
+
+			field := graphQLField{name: name}
+			field.args, end = scanGraphQLArgs(query, end)
+
+			fields = append(fields, field)
+			i = end - 1
+		}
+	}
+
+	return fields, spread
+}
+
+// scanGraphQLArgs returns the text between the parentheses of the
+// arguments following a field name ending at i, if any, and the index past
+// them.
+func scanGraphQLArgs(query string, i int) (args string, end int) {
+	next := skipGraphQLSpace(query, i)
+	if next >= len(query) || query[next] != '(' {
+		return "", i
+	}
+
+	close := next + 1
+	for close < len(query) && query[close] != ')' {
+		if query[close] == '"' {
+			close = skipGraphQLString(query, close)
+		}
+
+		close++
+	}
+
+	return query[next+1 : min(close, len(query))], close + 1
+}
+
+// graphQLSelectedFields returns every field a document selects, at any
+// depth and in fragments too, past aliases and directives.
+func graphQLSelectedFields(query string) []graphQLField {
+	var (
+		fields         []graphQLField
+		braces, parens int
+	)
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case c == '#':
+			i = skipGraphQLComment(query, i)
+
+		case c == '"':
+			i = skipGraphQLString(query, i)
+
+		case c == '(':
+			parens++
+
+		case c == ')':
+			parens--
+
+		case parens > 0:
+
+		case c == '{':
+			braces++
+
+		case c == '}':
+			braces--
+
+		case braces == 0:
+
+		case c == '.' && strings.HasPrefix(query[i:], "..."):
+			// Skip the fragment name, or the type condition of an inline
+			// fragment.
+			start := skipGraphQLSpace(query, i+3)
+			end := scanGraphQLName(query, start)
+
+			if query[start:end] == "on" {
+				end = scanGraphQLName(query, skipGraphQLSpace(query, end))
+			}
+
+			i = end - 1
+
+		case c == '@':
+			i = scanGraphQLName(query, i+1) - 1
+
+		case isNameStart(c):
+			end := scanGraphQLName(query, i)
+
+			// An alias is followed by a colon; the field name comes next.
+			if next := skipGraphQLSpace(query, end); next < len(query) && query[next] == ':' {
+				i = next
+				continue
+			}
+
+			field := graphQLField{name: query[i:end]}
+			field.args, end = scanGraphQLArgs(query, end)
+
+			fields = append(fields, field)
+			i = end - 1
+		}
+	}
+
+	return fields
+}
+
+// skipGraphQLComment returns the index of the end of the comment at i.
+func skipGraphQLComment(query string, i int) int {
+	for i < len(query) && query[i] != '\n' {
+		i++
+	}
+
+	return i
+}
+
+// skipGraphQLString returns the index of the closing quote of the string
+// or block string starting at i, or len(query) if it is unterminated.
+func skipGraphQLString(query string, i int) int {
+	if strings.HasPrefix(query[i:], `"""`) {
+		end := strings.Index(query[i+3:], `"""`)
+		if end < 0 {
+			return len(query)
+		}
+
+		return i + end + 5
+	}
+
+	for i++; i < len(query) && query[i] != '"'; i++ {
+		if query[i] == '\\' {
+			i++
+		}
+	}
+
+	return i
+}
+
+func skipGraphQLSpace(query string, i int) int {
+	for i < len(query) && strings.IndexByte(" \t\r\n,", query[i]) >= 0 {
+		i++
+	}
+
+	return i
+}
+
+func scanGraphQLName(query string, i int) int {
+	for i < len(query) && isNameChar(query[i]) {
+		i++
+	}
+
+	return i
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
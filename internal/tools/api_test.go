@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsMutation(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`{ viewer { login } }`, false},
+		{`query { viewer { login } }`, false},
+		{`mutation { addStar(input: {starrableId: "x"}) { clientMutationId } }`, true},
+		{`  # leading comment
+		mutation Star($id: ID!) { addStar(input: {starrableId: $id}) { clientMutationId } }`, true},
+		{`query { search(query: "mutation {") { issueCount } }`, false},
+		{`query Q { a } mutation M { b }`, true},
+		{`query { repository(owner: "o", name: "r") { mutation: name } }`, false},
+		{`fragment F on Mutation { x } query { y }`, false},
+		{`query { a(text: """mutation""") }`, false},
+	}
+
+	for _, tt := range tests {
+		if got := isMutation(tt.query); got != tt.want {
+			t.Errorf("isMutation(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestGraphQLRootFields(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		spread bool
+	}{
+		{`{ viewer { login } }`, "viewer", false},
+		{`query($o: String!) { repository(owner: $o, name: "r") { issues(first: 1) { nodes { id } } } rateLimit { remaining } }`, "repository rateLimit", false},
+		{`{ me: viewer { login } repo: repository(owner: "o", name: "r") { name } }`, "viewer repository", false},
+		{`{ viewer @include(if: true) { login } }`, "viewer", false},
+		{`# repository { }
+		{ node(id: "x") { id } }`, "node", false},
+		{`{ search(query: "repo:o/r )") { issueCount } }`, "search", false},
+		{`fragment F on Query { viewer { login } } query { ...F }`, "", true},
+		{`query { ... on Query { viewer { login } } }`, "", true},
+		{`mutation { addStar(input: {starrableId: "x"}) { clientMutationId } }`, "addStar", false},
+	}
+
+	for _, tt := range tests {
+		fields, spread := graphQLRootFields(tt.query)
+
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = f.name
+		}
+
+		if got := strings.Join(names, " "); got != tt.want || spread != tt.spread {
+			t.Errorf("graphQLRootFields(%q) = %q, %v; want %q, %v", tt.query, got, spread, tt.want, tt.spread)
+		}
+	}
+}
+
+func TestGraphQLSelectedFields(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`query($o: String!) { repository(owner: $o, name: "r") { issues(first: 1) { nodes { id } } } }`, "repository issues nodes id"},
+		{`{ repository(owner: "o", name: "r") { up: parent { nameWithOwner } } }`, "repository parent nameWithOwner"},
+		{`{ repository(owner: "o", name: "r") { ... on Repository { forks(first: 1) { totalCount } } } }`, "repository forks totalCount"},
+		{`fragment F on Repository { owner { login } } { repository(owner: "o", name: "r") { ...F } }`, "owner login repository"},
+		{`{ viewer @include(if: true) { login } }`, "viewer login"},
+	}
+
+	for _, tt := range tests {
+		var names []string
+		for _, f := range graphQLSelectedFields(tt.query) {
+			names = append(names, f.name)
+		}
+
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("graphQLSelectedFields(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/audit"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/redact"
+)
+
+// maxAuditInputBytes caps the stdin recorded per gh command, which for
+// file uploads can be large; queries and request bodies fit well within.
+const maxAuditInputBytes = 64 << 10
+
+// withAudit records every call to name, with the gh commands it ran and
+// what they read from stdin, in auditLog, masking credentials with r. It
+// wraps all other handler layers so arguments are logged as the client
+// sent them.
+func withAudit(auditLog *audit.Log, r *redact.Redactor, name string, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		trace := &gh.Trace{}
+		start := time.Now()
+
+		result, err := handler(gh.WithTrace(ctx, trace), args)
+
+		entry := audit.Entry{
+			Time:       start.UTC(),
+			Tool:       name,
+			Arguments:  json.RawMessage(r.Redact(string(args))),
+			DurationMS: time.Since(start).Milliseconds(),
+			IsError:    err != nil || (result != nil && result.IsError),
+		}
+
+		for _, inv := range trace.Invocations() {
+			input := inv.Stdin
+			if len(input) > maxAuditInputBytes {
+				input = input[:maxAuditInputBytes] + "... [truncated]"
+			}
+
+			entry.GH = append(entry.GH, audit.Command{Args: inv.Args, Host: inv.Host, Input: r.Redact(input), ExitCode: inv.ExitCode})
+		}
+
+		if result != nil {
+			for _, block := range result.Content {
+				entry.OutputBytes += len(block.Text)
+			}
+		}
+
+		if auditErr := auditLog.Write(entry); auditErr != nil {
+			log.Printf("audit: %v", auditErr)
+		}
+
+		return result, err
+	}
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/friedenberg/get-hubbed/internal/audit"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/gh/ghtest"
+	"github.com/friedenberg/get-hubbed/internal/redact"
+)
+
+func TestAuditRecordsToolCalls(t *testing.T) {
+	argv := []string{"issue", "view", "7", "-R", "octo/hello", "--json", "number,title,state,body,author,labels,assignees,comments,createdAt,updatedAt,url"}
+
+	ghtest.Install(t, ghtest.NewFake(gh.Invocation{Args: argv, Stdout: `{"number": 7}`}))
+
+	var buf bytes.Buffer
+
+	r := newToolRegistry(Options{Audit: audit.New(&buf), DefaultRepo: "octo/hello"})
+	registerTools(r)
+
+	i := slices.IndexFunc(r.tools, func(tl tool) bool { return tl.name == "issue_view" })
+	if i < 0 {
+		t.Fatal("issue_view not registered")
+	}
+
+	if _, err := r.tools[i].handler(context.Background(), json.RawMessage(`{"number": 7}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry audit.Entry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("parsing audit line %q: %v", buf.String(), err)
+	}
+
+	if entry.Tool != "issue_view" || string(entry.Arguments) != `{"number":7}` || entry.IsError {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	if len(entry.GH) != 1 || !slices.Equal(entry.GH[0].Args, argv) {
+		t.Errorf("gh commands = %+v, want %q", entry.GH, argv)
+	}
+
+	if entry.OutputBytes == 0 {
+		t.Error("output size not recorded")
+	}
+}
+
+func TestAuditRecordsGraphQLQueries(t *testing.T) {
+	token := "ghp_" + strings.Repeat("a1B2", 9)
+
+	ghtest.Install(t, ghtest.NewFake(gh.Invocation{
+		Args:   []string{"api", "graphql", "--include", "--input", "-"},
+		Stdout: "HTTP/2.0 200 OK\r\nContent-Type: application/json; charset=utf-8\r\n\r\n{\"data\": {\"addComment\": {\"clientMutationId\": null}}}\n",
+	}))
+
+	var buf bytes.Buffer
+
+	r := newToolRegistry(Options{Audit: audit.New(&buf), Redactor: redact.New(nil)})
+	registerTools(r)
+
+	i := slices.IndexFunc(r.tools, func(tl tool) bool { return tl.name == "graphql_mutation" })
+	if i < 0 {
+		t.Fatal("graphql_mutation not registered")
+	}
+
+	query := "mutation($id: ID!, $body: String!) { addComment(input: {subjectId: $id, body: $body}) { clientMutationId } }"
+	args, _ := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": map[string]any{"id": "I_kwDOabc", "body": "token " + token},
+	})
+
+	if _, err := r.tools[i].handler(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry audit.Entry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("parsing audit line %q: %v", buf.String(), err)
+	}
+
+	if len(entry.GH) != 1 {
+		t.Fatalf("gh commands = %+v, want one", entry.GH)
+	}
+
+	var input struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+
+	if err := json.Unmarshal([]byte(entry.GH[0].Input), &input); err != nil {
+		t.Fatalf("parsing recorded input %q: %v", entry.GH[0].Input, err)
+	}
+
+	if input.Query != query || input.Variables["id"] != "I_kwDOabc" {
+		t.Errorf("recorded input = %+v", input)
+	}
+
+	if strings.Contains(buf.String(), token) || input.Variables["body"] != "token [REDACTED:github_token]" {
+		t.Errorf("token not redacted from audit line %s", buf.String())
+	}
+}
@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+const cursorDescription = "Opaque cursor from a truncated result; pass it to the same tool with the same arguments to get the next chunk"
+
+// cursor points into the full output of an earlier call. ID names the
+// stored output; if it has been evicted the call is re-run and sliced at
+// Offset instead. Tool and Args tie the cursor to the call that made it,
+// so it cannot continue a different tool or different arguments.
+type cursor struct {
+	ID     string `json:"id"`
+	Offset int    `json:"offset"`
+	Tool   string `json:"tool"`
+	Args   string `json:"args"`
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(raw, &c)
+	}
+
+	if err != nil || c.ID == "" || c.Offset < 0 || c.Tool == "" {
+		return c, fmt.Errorf("invalid cursor %q", s)
+	}
+
+	return c, nil
+}
+
+const maxStoredOutputs = 32
+
+// argsHash identifies a call's arguments independent of key order and
+// whitespace.
+func argsHash(fields map[string]json.RawMessage) string {
+	canonical := make(map[string]any, len(fields))
+
+	for k, raw := range fields {
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			v = string(raw)
+		}
+
+		canonical[k] = v
+	}
+
+	raw, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// storedOutput is the full text of a truncated result and the call it
+// came from.
+type storedOutput struct {
+	tool, args string
+	text       string
+}
+
+// outputStore keeps the full text of recently truncated results so later
+// chunks come from the same snapshot without calling GitHub again. It is
+// shared by all tools; entries only match the tool and arguments that
+// stored them.
+type outputStore struct {
+	mu      sync.Mutex
+	outputs map[string]storedOutput
+	order   []string
+}
+
+func newOutputStore() *outputStore {
+	return &outputStore{outputs: make(map[string]storedOutput)}
+}
+
+func (s *outputStore) get(c cursor) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out, ok := s.outputs[c.ID]
+	if !ok || out.tool != c.Tool || out.args != c.Args {
+		return "", false
+	}
+
+	return out.text, true
+}
+
+func (s *outputStore) put(c cursor, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.outputs[c.ID]; !ok {
+		s.order = append(s.order, c.ID)
+	}
+
+	s.outputs[c.ID] = storedOutput{tool: c.Tool, args: c.Args, text: text}
+
+	for len(s.order) > maxStoredOutputs {
+		delete(s.outputs, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+func newOutputID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// addCursorProperty adds the cursor parameter every budgeted tool accepts.
+func addCursorProperty(schema json.RawMessage) json.RawMessage {
+	var s map[string]any
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return schema
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	if properties == nil {
+		properties = make(map[string]any)
+		s["properties"] = properties
+	}
+
+	properties["cursor"] = map[string]any{
+		"type":        "string",
+		"description": cursorDescription,
+	}
+
+	out, err := json.Marshal(s)
+	if err != nil {
+		return schema
+	}
+
+	return out
+}
+
+// withBudget limits a tool's output to budget bytes per call. Longer
+// output is cut at a line boundary where possible and ends with a cursor
+// for the next chunk, and carries no structured content.
+func withBudget(name string, budget int, store *outputStore, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(args, &fields); err != nil {
+			// Let the handler report malformed arguments.
+			return handler(ctx, args)
+		}
+
+		var encoded string
+
+		raw, hasCursor := fields["cursor"]
+		if hasCursor {
+			_ = json.Unmarshal(raw, &encoded)
+			delete(fields, "cursor")
+		}
+
+		c := cursor{Tool: name, Args: argsHash(fields)}
+
+		if hasCursor {
+			if encoded != "" {
+				given, err := decodeCursor(encoded)
+				if err != nil {
+					return errorResult(gh.KindValidation, "%v", err), nil
+				}
+
+				switch {
+				case given.Tool != c.Tool:
+					return errorResult(gh.KindValidation, "cursor belongs to %s, not %s", given.Tool, name), nil
+				case given.Args != c.Args:
+					return errorResult(gh.KindValidation, "cursor was issued for different arguments; pass the arguments of the call that returned it"), nil
+				}
+
+				c = given
+
+				if text, ok := store.get(c); ok {
+					return chunkResult(name, text, c, budget), nil
+				}
+			}
+
+			var err error
+			if args, err = json.Marshal(fields); err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := handler(ctx, args)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+
+		var sb strings.Builder
+		for _, block := range result.Content {
+			sb.WriteString(block.Text)
+		}
+
+		text := sb.String()
+
+		if c.Offset == 0 && len(text) <= budget {
+			return result, nil
+		}
+
+		dropStructuredContent(ctx)
+
+		if c.ID == "" {
+			c.ID = newOutputID()
+		}
+
+		store.put(c, text)
+
+		return chunkResult(name, text, c, budget), nil
+	}
+}
+
+func chunkResult(name, text string, c cursor, budget int) *protocol.ToolCallResult {
+	start := min(c.Offset, len(text))
+	end := cutPoint(text, start, budget)
+
+	blocks := []protocol.ContentBlock{protocol.TextContent(text[start:end])}
+
+	if end < len(text) {
+		next := c
+		next.Offset = end
+
+		blocks = append(blocks, protocol.TextContent(fmt.Sprintf(
+			"[output truncated: bytes %d-%d of %d; call %s again with \"cursor\": %q to continue]",
+			start, end, len(text), name, encodeCursor(next),
+		)))
+	}
+
+	return &protocol.ToolCallResult{Content: blocks}
+}
+
+// cutPoint picks where a chunk starting at start ends: after the last
+// newline in the budget if that keeps at least half of it, otherwise at
+// the last rune boundary.
+func cutPoint(text string, start, budget int) int {
+	end := start + budget
+	if end >= len(text) {
+		return len(text)
+	}
+
+	if i := strings.LastIndexByte(text[start:end], '\n'); i >= budget/2 {
+		return start + i + 1
+	}
+
+	for end > start && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	if end == start {
+		// A budget smaller than one rune still has to make progress.
+		_, size := utf8.DecodeRuneInString(text[start:])
+		end = start + size
+	}
+
+	return end
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+)
+
+var cursorPattern = regexp.MustCompile(`"cursor": "([^"]+)"`)
+
+func TestBudgetChunksOutputBehindCursor(t *testing.T) {
+	full := strings.Repeat("line of log output\n", 20)
+
+	calls := 0
+	handler := func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		calls++
+
+		if strings.Contains(string(args), "cursor") {
+			t.Errorf("handler saw cursor in %s", args)
+		}
+
+		return &protocol.ToolCallResult{Content: []protocol.ContentBlock{protocol.TextContent(full)}}, nil
+	}
+
+	for _, evict := range []bool{false, true} {
+		calls = 0
+		store := newOutputStore()
+		budgeted := withBudget("run_log", 100, store, handler)
+
+		var got strings.Builder
+
+		args := `{"run_id": 1}`
+		for i := 0; ; i++ {
+			if i > 20 {
+				t.Fatal("cursor never ran out")
+			}
+
+			result, err := budgeted(context.Background(), json.RawMessage(args))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			chunk := result.Content[0].Text
+			if len(chunk) > 100 || !strings.HasSuffix(chunk, "\n") {
+				t.Errorf("chunk %d not cut at a line within budget: %q", i, chunk)
+			}
+
+			got.WriteString(chunk)
+
+			if len(result.Content) == 1 {
+				break
+			}
+
+			m := cursorPattern.FindStringSubmatch(result.Content[1].Text)
+			if m == nil {
+				t.Fatalf("no cursor in %q", result.Content[1].Text)
+			}
+
+			if evict {
+				store = newOutputStore()
+				budgeted = withBudget("run_log", 100, store, handler)
+			}
+
+			args = `{"run_id": 1, "cursor": "` + m[1] + `"}`
+		}
+
+		if got.String() != full {
+			t.Errorf("chunks do not reassemble the output (evict=%v)", evict)
+		}
+
+		if !evict && calls != 1 {
+			t.Errorf("handler called %d times, want 1", calls)
+		}
+	}
+}
+
+func TestBudgetPassesSmallOutputThrough(t *testing.T) {
+	handler := func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		return &protocol.ToolCallResult{Content: []protocol.ContentBlock{protocol.TextContent("short")}}, nil
+	}
+
+	result, err := withBudget("repo_view", 100, newOutputStore(), handler)(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Content) != 1 || result.Content[0].Text != "short" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestBudgetRejectsCursorFromAnotherCall(t *testing.T) {
+	handler := func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		return &protocol.ToolCallResult{Content: []protocol.ContentBlock{protocol.TextContent(strings.Repeat("x\n", 100))}}, nil
+	}
+
+	store := newOutputStore()
+	runLog := withBudget("run_log", 50, store, handler)
+	prDiff := withBudget("pr_diff", 50, store, handler)
+
+	result, err := runLog(context.Background(), json.RawMessage(`{"run_id": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := cursorPattern.FindStringSubmatch(result.Content[len(result.Content)-1].Text)
+	if m == nil {
+		t.Fatalf("no cursor in %+v", result)
+	}
+
+	tests := []struct {
+		name    string
+		handler handlerFunc
+		args    string
+		want    string
+	}{
+		{"same call", runLog, `{"run_id":1,"cursor":"` + m[1] + `"}`, ""},
+		{"other arguments", runLog, `{"run_id": 2, "cursor": "` + m[1] + `"}`, "cursor was issued for different arguments"},
+		{"other tool", prDiff, `{"run_id": 1, "cursor": "` + m[1] + `"}`, "cursor belongs to run_log, not pr_diff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.handler(context.Background(), json.RawMessage(tt.args))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			text := result.Content[0].Text
+
+			switch {
+			case tt.want == "" && result.IsError:
+				t.Errorf("unexpected error %q", text)
+			case tt.want != "" && (!result.IsError || !strings.Contains(text, tt.want)):
+				t.Errorf("got %q (error %v), want error containing %q", text, result.IsError, tt.want)
+			}
+		})
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/amarbel-llc/go-lib-mcp/protocol"
-	"github.com/amarbel-llc/go-lib-mcp/server"
 	"github.com/friedenberg/get-hubbed/internal/gh"
 )
 
-func registerContentTools(r *server.ToolRegistry) {
+func registerContentTools(r *toolRegistry) {
 	r.Register(
 		"content_tree",
 		"List directory contents of a repository at a given path and ref",
@@ -21,15 +24,18 @@ func registerContentTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"ref": {
 					"type": "string",
-					"description": "Git ref (branch, tag, or SHA). Defaults to the repo's default branch"
+					"description": "Git ref (branch, tag, or SHA). Defaults to the repo's default branch",
+					"format": "git-ref"
 				},
 				"path": {
 					"type": "string",
-					"description": "Directory path within the repo (e.g. 'src/lib'). Defaults to repo root"
+					"description": "Directory path within the repo (e.g. 'src/lib'). Defaults to repo root",
+					"format": "repo-path"
 				},
 				"recursive": {
 					"type": "boolean",
@@ -37,16 +43,19 @@ func registerContentTools(r *server.ToolRegistry) {
 				},
 				"limit": {
 					"type": "integer",
-					"description": "Maximum number of entries to return"
+					"description": "Maximum number of entries to return",
+					"minimum": 1
 				},
 				"offset": {
 					"type": "integer",
-					"description": "Number of entries to skip for pagination"
+					"description": "Number of entries to skip for pagination",
+					"minimum": 0
 				}
-			},
-			"required": ["repo"]
+			}
 		}`),
 		handleContentTree,
+		structured[TreeListing](),
+		outputColumns("path", "type", "size", "sha"),
 	)
 
 	r.Register(
@@ -57,26 +66,31 @@ func registerContentTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"path": {
 					"type": "string",
-					"description": "File path within the repo (e.g. 'src/main.go')"
+					"description": "File path within the repo (e.g. 'src/main.go')",
+					"format": "repo-path"
 				},
 				"ref": {
 					"type": "string",
-					"description": "Git ref (branch, tag, or SHA). Defaults to the repo's default branch"
+					"description": "Git ref (branch, tag, or SHA). Defaults to the repo's default branch",
+					"format": "git-ref"
 				},
 				"line_offset": {
 					"type": "integer",
-					"description": "Start reading from this line number (1-based). Defaults to 1"
+					"description": "Start reading from this line number (1-based). Defaults to 1",
+					"minimum": 1
 				},
 				"line_limit": {
 					"type": "integer",
-					"description": "Maximum number of lines to return. Defaults to all lines"
+					"description": "Maximum number of lines to return. Defaults to all lines",
+					"minimum": 1
 				}
 			},
-			"required": ["repo", "path"]
+			"required": ["path"]
 		}`),
 		handleContentRead,
 	)
@@ -89,28 +103,34 @@ func registerContentTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"path": {
 					"type": "string",
-					"description": "File path within the repo"
+					"description": "File path within the repo",
+					"format": "repo-path"
 				},
 				"ref": {
 					"type": "string",
-					"description": "Git ref (branch, tag, or SHA). Defaults to HEAD"
+					"description": "Git ref (branch, tag, or SHA). Defaults to HEAD",
+					"format": "git-ref"
 				},
 				"start_line": {
 					"type": "integer",
-					"description": "Start line of the range to blame (1-based)"
+					"description": "Start line of the range to blame (1-based)",
+					"minimum": 1
 				},
 				"end_line": {
 					"type": "integer",
-					"description": "End line of the range to blame (1-based, inclusive)"
+					"description": "End line of the range to blame (1-based, inclusive)",
+					"minimum": 1
 				}
 			},
-			"required": ["repo", "path"]
+			"required": ["path"]
 		}`),
 		handleContentBlame,
+		structured[Blame](),
 	)
 
 	r.Register(
@@ -121,28 +141,35 @@ func registerContentTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"path": {
 					"type": "string",
-					"description": "File or directory path to get commit history for"
+					"description": "File or directory path to get commit history for",
+					"format": "repo-path"
 				},
 				"ref": {
 					"type": "string",
-					"description": "Branch or tag name to list commits from. Defaults to the repo's default branch"
+					"description": "Branch or tag name to list commits from. Defaults to the repo's default branch",
+					"format": "git-ref"
 				},
 				"per_page": {
 					"type": "integer",
-					"description": "Number of commits per page (max 100, default 30)"
+					"description": "Number of commits per page (max 100, default 30)",
+					"minimum": 1,
+					"maximum": 100
 				},
 				"page": {
 					"type": "integer",
-					"description": "Page number for pagination (default 1)"
+					"description": "Page number for pagination (default 1)",
+					"minimum": 1
 				}
 			},
-			"required": ["repo", "path"]
+			"required": ["path"]
 		}`),
 		handleContentCommits,
+		structured[[]CommitSummary](),
 	)
 
 	r.Register(
@@ -153,28 +180,35 @@ func registerContentTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"base": {
 					"type": "string",
-					"description": "Base ref (branch, tag, or SHA)"
+					"description": "Base ref (branch, tag, or SHA)",
+					"format": "git-ref"
 				},
 				"head": {
 					"type": "string",
-					"description": "Head ref (branch, tag, or SHA)"
+					"description": "Head ref (branch, tag, or SHA)",
+					"format": "git-ref"
 				},
 				"per_page": {
 					"type": "integer",
-					"description": "Number of file entries per page (max 100, default 30)"
+					"description": "Number of file entries per page (max 100, default 30)",
+					"minimum": 1,
+					"maximum": 100
 				},
 				"page": {
 					"type": "integer",
-					"description": "Page number for pagination (default 1)"
+					"description": "Page number for pagination (default 1)",
+					"minimum": 1
 				}
 			},
-			"required": ["repo", "base", "head"]
+			"required": ["base", "head"]
 		}`),
 		handleContentCompare,
+		structured[Comparison](),
 	)
 
 	r.Register(
@@ -185,7 +219,8 @@ func registerContentTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"query": {
 					"type": "string",
@@ -193,7 +228,8 @@ func registerContentTools(r *server.ToolRegistry) {
 				},
 				"path": {
 					"type": "string",
-					"description": "Restrict search to a file path or directory prefix"
+					"description": "Restrict search to a file path or directory prefix",
+					"format": "repo-path"
 				},
 				"extension": {
 					"type": "string",
@@ -201,16 +237,20 @@ func registerContentTools(r *server.ToolRegistry) {
 				},
 				"per_page": {
 					"type": "integer",
-					"description": "Number of results per page (max 100, default 30)"
+					"description": "Number of results per page (max 100, default 30)",
+					"minimum": 1,
+					"maximum": 100
 				},
 				"page": {
 					"type": "integer",
-					"description": "Page number for pagination (default 1)"
+					"description": "Page number for pagination (default 1)",
+					"minimum": 1
 				}
 			},
-			"required": ["repo", "query"]
+			"required": ["query"]
 		}`),
 		handleContentSearch,
+		structured[CodeSearchResult](),
 	)
 }
 
@@ -225,7 +265,7 @@ func handleContentTree(ctx context.Context, args json.RawMessage) (*protocol.Too
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
 	ref := params.Ref
@@ -238,23 +278,31 @@ func handleContentTree(ctx context.Context, args json.RawMessage) (*protocol.Too
 		treeSha = ref + ":" + params.Path
 	}
 
-	ghArgs := []string{
-		"api",
-		fmt.Sprintf("repos/%s/git/trees/%s", params.Repo, treeSha),
-		"--method", "GET",
+	req := gh.Request{
+		Method:    http.MethodGet,
+		Path:      fmt.Sprintf("repos/%s/git/trees/%s", params.Repo, treeSha),
+		Immutable: gh.IsCommitSHA(ref),
 	}
 
 	if params.Recursive {
-		ghArgs = append(ghArgs, "-f", "recursive=1")
+		req.Params = url.Values{"recursive": {"1"}}
 	}
 
-	ghArgs = append(ghArgs, "--jq", ".tree")
-
-	out, err := gh.Run(ctx, ghArgs...)
+	resp, err := gh.Default().REST(ctx, req)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api git/trees: %v", err)), nil
+		return ghErrorResult("gh api git/trees", err), nil
+	}
+
+	var treeResp struct {
+		Tree json.RawMessage `json:"tree"`
+	}
+
+	if err := json.Unmarshal(resp.Body, &treeResp); err != nil {
+		return errorResult(kindInternal, "parsing tree response: %v", err), nil
 	}
 
+	out := string(treeResp.Tree)
+
 	if params.Offset > 0 || params.Limit > 0 {
 		var entries []json.RawMessage
 		if err := json.Unmarshal([]byte(out), &entries); err != nil {
@@ -292,7 +340,7 @@ func handleContentTree(ctx context.Context, args json.RawMessage) (*protocol.Too
 
 		resultJSON, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return protocol.ErrorResult(fmt.Sprintf("marshaling paginated result: %v", err)), nil
+			return errorResult(kindInternal, "marshaling paginated result: %v", err), nil
 		}
 
 		return &protocol.ToolCallResult{
@@ -309,6 +357,74 @@ func handleContentTree(ctx context.Context, args json.RawMessage) (*protocol.Too
 	}, nil
 }
 
+// fileContent is a file as the contents API returns it.
+type fileContent struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+	Size     int    `json:"size"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	SHA      string `json:"sha"`
+}
+
+// decode returns the file's bytes from the base64 the API wraps at 60
+// columns.
+func (f fileContent) decode() ([]byte, error) {
+	if f.Encoding != "base64" {
+		return nil, fmt.Errorf("unexpected encoding: %s", f.Encoding)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(f.Content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64 content: %w", err)
+	}
+
+	return decoded, nil
+}
+
+// contentsRequest fetches a file or directory at ref, or at the default
+// branch when ref is empty.
+func contentsRequest(repo, filePath, ref string) gh.Request {
+	req := gh.Request{
+		Method:    http.MethodGet,
+		Path:      fmt.Sprintf("repos/%s/contents/%s", repo, filePath),
+		Immutable: gh.IsCommitSHA(ref),
+	}
+
+	if ref != "" {
+		req.Params = url.Values{"ref": {ref}}
+	}
+
+	return req
+}
+
+// readRepoFile returns a file's content at ref, or the empty string when
+// the repository has no such file.
+func readRepoFile(ctx context.Context, repo, filePath, ref string) (string, error) {
+	resp, err := gh.Default().REST(ctx, contentsRequest(repo, filePath, ref))
+	if err != nil {
+		var ghErr *gh.Error
+		if errors.As(err, &ghErr) && ghErr.Kind == gh.KindNotFound {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	var file fileContent
+	if err := json.Unmarshal(resp.Body, &file); err != nil {
+		return "", fmt.Errorf("parsing %s: %w", filePath, err)
+	}
+
+	decoded, err := file.decode()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	return string(decoded), nil
+}
+
 func handleContentRead(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
 		Repo       string `json:"repo"`
@@ -319,24 +435,16 @@ func handleContentRead(ctx context.Context, args json.RawMessage) (*protocol.Too
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
-	ghArgs := []string{
-		"api",
-		fmt.Sprintf("repos/%s/contents/%s", params.Repo, params.Path),
-		"--method", "GET",
-	}
-
-	if params.Ref != "" {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("ref=%s", params.Ref))
-	}
-
-	out, err := gh.Run(ctx, ghArgs...)
+	resp, err := gh.Default().REST(ctx, contentsRequest(params.Repo, params.Path, params.Ref))
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api contents: %v", err)), nil
+		return ghErrorResult("gh api contents", err), nil
 	}
 
+	out := string(resp.Body)
+
 	// GitHub API returns an array for directories, detect this before unmarshaling
 	trimmed := strings.TrimSpace(out)
 	if len(trimmed) > 0 && trimmed[0] == '[' {
@@ -347,18 +455,10 @@ func handleContentRead(ctx context.Context, args json.RawMessage) (*protocol.Too
 		}, nil
 	}
 
-	var contentResp struct {
-		Content  string `json:"content"`
-		Encoding string `json:"encoding"`
-		Size     int    `json:"size"`
-		Name     string `json:"name"`
-		Path     string `json:"path"`
-		Type     string `json:"type"`
-		SHA      string `json:"sha"`
-	}
+	var contentResp fileContent
 
 	if err := json.Unmarshal([]byte(out), &contentResp); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("parsing content response: %v", err)), nil
+		return errorResult(kindInternal, "parsing content response: %v", err), nil
 	}
 
 	if contentResp.Type == "dir" {
@@ -369,15 +469,9 @@ func handleContentRead(ctx context.Context, args json.RawMessage) (*protocol.Too
 		}, nil
 	}
 
-	if contentResp.Encoding != "base64" {
-		return protocol.ErrorResult(fmt.Sprintf("unexpected encoding: %s", contentResp.Encoding)), nil
-	}
-
-	decoded, err := base64.StdEncoding.DecodeString(
-		strings.ReplaceAll(contentResp.Content, "\n", ""),
-	)
+	decoded, err := contentResp.decode()
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("decoding base64 content: %v", err)), nil
+		return errorResult(kindInternal, "%v", err), nil
 	}
 
 	text := string(decoded)
@@ -419,6 +513,29 @@ func handleContentRead(ctx context.Context, args json.RawMessage) (*protocol.Too
 	}, nil
 }
 
+const blameQuery = `query($owner: String!, $name: String!, $expression: String!, $path: String!) {
+  repository(owner: $owner, name: $name) {
+    object(expression: $expression) {
+      ... on Commit {
+        blame(path: $path) {
+          ranges {
+            startingLine
+            endingLine
+            commit {
+              oid
+              message
+              author {
+                name
+                date
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
 func handleContentBlame(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
 		Repo      string `json:"repo"`
@@ -429,47 +546,31 @@ func handleContentBlame(ctx context.Context, args json.RawMessage) (*protocol.To
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
-	parts := strings.SplitN(params.Repo, "/", 2)
-	owner, name := parts[0], parts[1]
+	owner, name, ok := strings.Cut(params.Repo, "/")
+	if !ok {
+		return errorResult(gh.KindValidation, "repo %q is not in OWNER/REPO format", params.Repo), nil
+	}
 
 	ref := params.Ref
 	if ref == "" {
 		ref = "HEAD"
 	}
 
-	query := fmt.Sprintf(`query {
-		repository(owner: %q, name: %q) {
-			object(expression: %q) {
-				... on Commit {
-					blame(path: %q) {
-						ranges {
-							startingLine
-							endingLine
-							commit {
-								oid
-								message
-								author {
-									name
-									date
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	}`, owner, name, ref, params.Path)
-
-	ghArgs := []string{"api", "graphql", "-f", fmt.Sprintf("query=%s", query)}
-
-	out, err := gh.Run(ctx, ghArgs...)
+	resp, err := gh.Default().GraphQL(ctx, blameQuery, map[string]any{
+		"owner":      owner,
+		"name":       name,
+		"expression": ref,
+		"path":       params.Path,
+	})
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api graphql blame: %v", err)), nil
+		return ghErrorResult("gh api graphql blame", err), nil
 	}
 
+	out := string(resp.Body)
+
 	if params.StartLine > 0 || params.EndLine > 0 {
 		var result struct {
 			Data struct {
@@ -519,7 +620,7 @@ func handleContentBlame(ctx context.Context, args json.RawMessage) (*protocol.To
 
 		filteredJSON, err := json.MarshalIndent(filtered, "", "  ")
 		if err != nil {
-			return protocol.ErrorResult(fmt.Sprintf("marshaling filtered blame: %v", err)), nil
+			return errorResult(kindInternal, "marshaling filtered blame: %v", err), nil
 		}
 
 		return &protocol.ToolCallResult{
@@ -546,41 +647,83 @@ func handleContentCommits(ctx context.Context, args json.RawMessage) (*protocol.
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
-	endpoint := fmt.Sprintf("repos/%s/commits", params.Repo)
-
-	ghArgs := []string{"api", endpoint, "--method", "GET", "-f", fmt.Sprintf("path=%s", params.Path)}
+	req := gh.Request{
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("repos/%s/commits", params.Repo),
+		Params: url.Values{"path": {params.Path}},
+	}
 
 	if params.Ref != "" {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("sha=%s", params.Ref))
+		req.Params.Set("sha", params.Ref)
 	}
 
-	if params.PerPage > 0 {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("per_page=%d", params.PerPage))
+	addPageParams(req.Params, params.PerPage, params.Page)
+
+	resp, err := gh.Default().REST(ctx, req)
+	if err != nil {
+		return ghErrorResult("gh api commits", err), nil
 	}
 
-	if params.Page > 0 {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("page=%d", params.Page))
+	var commits []apiCommit
+	if err := json.Unmarshal(resp.Body, &commits); err != nil {
+		return errorResult(kindInternal, "parsing commits: %v", err), nil
 	}
 
-	ghArgs = append(ghArgs, "--jq",
-		`[.[] | {sha: .sha, message: .commit.message, author: .commit.author.name, date: .commit.author.date, url: .html_url}]`,
-	)
+	summaries := make([]CommitSummary, len(commits))
+	for i, c := range commits {
+		summaries[i] = c.summary()
+		summaries[i].URL = c.HTMLURL
+	}
 
-	out, err := gh.Run(ctx, ghArgs...)
+	resultJSON, err := json.MarshalIndent(summaries, "", "  ")
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api commits: %v", err)), nil
+		return errorResult(kindInternal, "marshaling commits: %v", err), nil
 	}
 
 	return &protocol.ToolCallResult{
 		Content: []protocol.ContentBlock{
-			protocol.TextContent(out),
+			protocol.TextContent(string(resultJSON)),
 		},
 	}, nil
 }
 
+// apiCommit is a commit as the REST API lists it.
+type apiCommit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+			Date string `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+}
+
+func (c apiCommit) summary() CommitSummary {
+	return CommitSummary{
+		SHA:     c.SHA,
+		Message: c.Commit.Message,
+		Author:  c.Commit.Author.Name,
+		Date:    c.Commit.Author.Date,
+	}
+}
+
+// addPageParams sets the per_page and page parameters of a REST request
+// when they are given.
+func addPageParams(params url.Values, perPage, page int) {
+	if perPage > 0 {
+		params.Set("per_page", strconv.Itoa(perPage))
+	}
+
+	if page > 0 {
+		params.Set("page", strconv.Itoa(page))
+	}
+}
+
 func handleContentCompare(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
 		Repo    string `json:"repo"`
@@ -591,33 +734,61 @@ func handleContentCompare(ctx context.Context, args json.RawMessage) (*protocol.
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	req := gh.Request{
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("repos/%s/compare/%s...%s", params.Repo, params.Base, params.Head),
+		Params: make(url.Values),
 	}
 
-	endpoint := fmt.Sprintf("repos/%s/compare/%s...%s", params.Repo, params.Base, params.Head)
+	addPageParams(req.Params, params.PerPage, params.Page)
 
-	ghArgs := []string{"api", endpoint, "--method", "GET"}
+	resp, err := gh.Default().REST(ctx, req)
+	if err != nil {
+		return ghErrorResult("gh api compare", err), nil
+	}
 
-	if params.PerPage > 0 {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("per_page=%d", params.PerPage))
+	var payload struct {
+		Status       string        `json:"status"`
+		AheadBy      int           `json:"ahead_by"`
+		BehindBy     int           `json:"behind_by"`
+		TotalCommits int           `json:"total_commits"`
+		Commits      []apiCommit   `json:"commits"`
+		Files        []ChangedFile `json:"files"`
 	}
 
-	if params.Page > 0 {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("page=%d", params.Page))
+	if err := json.Unmarshal(resp.Body, &payload); err != nil {
+		return errorResult(kindInternal, "parsing comparison: %v", err), nil
 	}
 
-	ghArgs = append(ghArgs, "--jq",
-		`{status, ahead_by, behind_by, total_commits, commits: [.commits[] | {sha: .sha[:8], message: .commit.message, author: .commit.author.name, date: .commit.author.date}], files: [.files[] | {filename, status, additions, deletions, changes}]}`,
-	)
+	comparison := Comparison{
+		Status:       payload.Status,
+		AheadBy:      payload.AheadBy,
+		BehindBy:     payload.BehindBy,
+		TotalCommits: payload.TotalCommits,
+		Commits:      make([]CommitSummary, len(payload.Commits)),
+		Files:        payload.Files,
+	}
+
+	for i, c := range payload.Commits {
+		comparison.Commits[i] = c.summary()
+		comparison.Commits[i].SHA = c.SHA[:min(len(c.SHA), 8)]
+	}
+
+	if comparison.Files == nil {
+		comparison.Files = []ChangedFile{}
+	}
 
-	out, err := gh.Run(ctx, ghArgs...)
+	resultJSON, err := json.MarshalIndent(comparison, "", "  ")
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api compare: %v", err)), nil
+		return errorResult(kindInternal, "marshaling comparison: %v", err), nil
 	}
 
 	return &protocol.ToolCallResult{
 		Content: []protocol.ContentBlock{
-			protocol.TextContent(out),
+			protocol.TextContent(string(resultJSON)),
 		},
 	}, nil
 }
@@ -633,7 +804,7 @@ func handleContentSearch(ctx context.Context, args json.RawMessage) (*protocol.T
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
 	q := fmt.Sprintf("%s repo:%s", params.Query, params.Repo)
@@ -646,33 +817,51 @@ func handleContentSearch(ctx context.Context, args json.RawMessage) (*protocol.T
 		q += fmt.Sprintf(" extension:%s", params.Extension)
 	}
 
-	ghArgs := []string{
-		"api", "search/code",
-		"--method", "GET",
-		"-H", "Accept: application/vnd.github.text-match+json",
-		"-f", fmt.Sprintf("q=%s", q),
+	req := gh.Request{
+		Method:  http.MethodGet,
+		Path:    "search/code",
+		Params:  url.Values{"q": {q}},
+		Headers: http.Header{"Accept": {"application/vnd.github.text-match+json"}},
+	}
+
+	addPageParams(req.Params, params.PerPage, params.Page)
+
+	resp, err := gh.Default().REST(ctx, req)
+	if err != nil {
+		return ghErrorResult("gh api search/code", err), nil
 	}
 
-	if params.PerPage > 0 {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("per_page=%d", params.PerPage))
+	var payload struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			CodeSearchItem
+			HTMLURL string `json:"html_url"`
+		} `json:"items"`
 	}
 
-	if params.Page > 0 {
-		ghArgs = append(ghArgs, "-f", fmt.Sprintf("page=%d", params.Page))
+	if err := json.Unmarshal(resp.Body, &payload); err != nil {
+		return errorResult(kindInternal, "parsing code search results: %v", err), nil
 	}
 
-	ghArgs = append(ghArgs, "--jq",
-		`{total_count, items: [.items[] | {name, path, sha, url: .html_url, score, text_matches: [.text_matches[]? | {fragment, matches: .matches}]}]}`,
-	)
+	result := CodeSearchResult{TotalCount: payload.TotalCount, Items: make([]CodeSearchItem, len(payload.Items))}
 
-	out, err := gh.Run(ctx, ghArgs...)
+	for i, item := range payload.Items {
+		result.Items[i] = item.CodeSearchItem
+		result.Items[i].URL = item.HTMLURL
+
+		if result.Items[i].TextMatches == nil {
+			result.Items[i].TextMatches = []TextMatch{}
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh api search/code: %v", err)), nil
+		return errorResult(kindInternal, "marshaling code search results: %v", err), nil
 	}
 
 	return &protocol.ToolCallResult{
 		Content: []protocol.ContentBlock{
-			protocol.TextContent(out),
+			protocol.TextContent(string(resultJSON)),
 		},
 	}, nil
 }
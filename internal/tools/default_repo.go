@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+var errNoDefaultRepo = errors.New("repo is required: no default repository could be resolved from the working directory")
+
+// takesRepo reports whether schema declares a repo property.
+func takesRepo(schema json.RawMessage) bool {
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return false
+	}
+
+	_, ok := s.Properties["repo"]
+
+	return ok
+}
+
+// describeDefaultRepo names the resolved default in the repo property's
+// description so the agent can see which repository an omitted repo means.
+func describeDefaultRepo(schema json.RawMessage, repo string) json.RawMessage {
+	var s map[string]any
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return schema
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	property, _ := properties["repo"].(map[string]any)
+	if property == nil {
+		return schema
+	}
+
+	property["description"] = "Repository in OWNER/REPO format; defaults to " + repo
+
+	out, err := json.Marshal(s)
+	if err != nil {
+		return schema
+	}
+
+	return out
+}
+
+// withDefaultRepo fills in repo when a call leaves it out or empty. It
+// wraps the policy check so the default is checked like any other repo.
+func withDefaultRepo(repo string, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(args, &fields); err != nil && len(args) > 0 {
+			// Let the handler report malformed arguments.
+			return handler(ctx, args)
+		}
+
+		var current string
+		if raw, ok := fields["repo"]; ok {
+			_ = json.Unmarshal(raw, &current)
+		}
+
+		if current != "" {
+			return handler(ctx, args)
+		}
+
+		if repo == "" {
+			return errorResult(gh.KindValidation, "%v", errNoDefaultRepo), nil
+		}
+
+		if fields == nil {
+			fields = make(map[string]json.RawMessage)
+		}
+
+		fields["repo"], _ = json.Marshal(repo)
+
+		filled, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, filled)
+	}
+}
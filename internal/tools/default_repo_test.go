@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/policy"
+)
+
+func TestDefaultRepoFillsOmittedRepo(t *testing.T) {
+	var got string
+
+	handler := func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		var params struct {
+			Repo string `json:"repo"`
+		}
+
+		if err := json.Unmarshal(args, &params); err != nil {
+			t.Fatal(err)
+		}
+
+		got = params.Repo
+
+		return protocol.ErrorResult("ok"), nil
+	}
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{}`, "octo/hello"},
+		{`{"repo": ""}`, "octo/hello"},
+		{`{"repo": "octo/other"}`, "octo/other"},
+		{``, "octo/hello"},
+	}
+
+	for _, tt := range tests {
+		got = ""
+
+		if _, err := withDefaultRepo("octo/hello", handler)(context.Background(), json.RawMessage(tt.args)); err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("args %s: handler saw repo %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	result, err := withDefaultRepo("", handler)(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.IsError || !strings.Contains(result.Content[0].Text, errNoDefaultRepo.Error()) {
+		t.Errorf("expected missing-repo error, got %+v", result)
+	}
+}
+
+func TestDefaultRepoIsCheckedByPolicy(t *testing.T) {
+	p, err := policy.New(nil, []string{"octo/secret"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newToolRegistry(Options{Policy: p, DefaultRepo: "octo/secret"})
+	registerTools(r)
+
+	for _, tl := range r.tools {
+		if tl.name != "issue_list" {
+			continue
+		}
+
+		if !takesRepo(tl.schema) {
+			t.Fatal("issue_list schema lost its repo property")
+		}
+
+		result, err := tl.handler(context.Background(), json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.IsError {
+			t.Error("default repo bypassed the deny list")
+		}
+
+		return
+	}
+
+	t.Fatal("issue_list not registered")
+}
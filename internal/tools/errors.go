@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// Kinds for failures that happen in this server rather than on GitHub.
+const (
+	kindPolicy   gh.ErrorKind = "policy"
+	kindInternal gh.ErrorKind = "internal"
+)
+
+// errorPayload is the body of every error result, so agents can branch on
+// kind instead of parsing the message.
+type errorPayload struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Kind             gh.ErrorKind     `json:"kind"`
+	Message          string           `json:"message"`
+	Status           int              `json:"status,omitempty"`
+	DocumentationURL string           `json:"documentation_url,omitempty"`
+	RateLimit        *rateLimitDetail `json:"rate_limit,omitempty"`
+}
+
+type rateLimitDetail struct {
+	Kind              string `json:"kind"`
+	Resource          string `json:"resource,omitempty"`
+	Reset             string `json:"reset,omitempty"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
+}
+
+func errorResult(kind gh.ErrorKind, format string, args ...any) *protocol.ToolCallResult {
+	return detailResult(errorDetail{Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+// ghErrorResult reports a failed gh execution or API call, carrying over
+// the classification and HTTP details of a *gh.Error.
+func ghErrorResult(op string, err error) *protocol.ToolCallResult {
+	detail := errorDetail{
+		Kind:    gh.KindUnknown,
+		Message: fmt.Sprintf("%s: %v", op, err),
+	}
+
+	var ghErr *gh.Error
+	if errors.As(err, &ghErr) {
+		detail.Kind = ghErr.Kind
+		detail.Status = ghErr.StatusCode
+		detail.DocumentationURL = ghErr.DocumentationURL
+
+		if rl := ghErr.RateLimit; rl != nil {
+			detail.RateLimit = &rateLimitDetail{
+				Kind:              rl.Kind,
+				Resource:          rl.Resource,
+				RetryAfterSeconds: int(rl.Wait(time.Now()).Round(time.Second).Seconds()),
+			}
+
+			if !rl.Reset.IsZero() {
+				detail.RateLimit.Reset = rl.Reset.UTC().Format(time.RFC3339)
+			}
+		}
+	}
+
+	return detailResult(detail)
+}
+
+func detailResult(detail errorDetail) *protocol.ToolCallResult {
+	out, err := json.MarshalIndent(errorPayload{Error: detail}, "", "  ")
+	if err != nil {
+		return protocol.ErrorResult(detail.Message)
+	}
+
+	return protocol.ErrorResult(string(out))
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// JSON fields each gh command accepts for --json, as of gh 2.x.
+var (
+	issueFields = []string{
+		"assignees", "author", "body", "closed", "closedAt", "comments", "createdAt", "id",
+		"isPinned", "labels", "milestone", "number", "projectCards", "projectItems",
+		"reactionGroups", "state", "stateReason", "title", "updatedAt", "url",
+	}
+
+	prFields = []string{
+		"additions", "assignees", "author", "autoMergeRequest", "baseRefName", "baseRefOid",
+		"body", "changedFiles", "closed", "closedAt", "closingIssuesReferences", "comments",
+		"commits", "createdAt", "deletions", "files", "fullDatabaseId", "headRefName",
+		"headRefOid", "headRepository", "headRepositoryOwner", "id", "isCrossRepository",
+		"isDraft", "labels", "latestReviews", "maintainerCanModify", "mergeCommit",
+		"mergeStateStatus", "mergeable", "mergedAt", "mergedBy", "milestone", "number",
+		"potentialMergeCommit", "projectCards", "projectItems", "reactionGroups",
+		"reviewDecision", "reviewRequests", "reviews", "state", "statusCheckRollup", "title",
+		"updatedAt", "url",
+	}
+
+	repoFields = []string{
+		"archivedAt", "assignableUsers", "codeOfConduct", "contactLinks", "createdAt",
+		"defaultBranchRef", "deleteBranchOnMerge", "description", "diskUsage", "forkCount",
+		"fundingLinks", "hasDiscussionsEnabled", "hasIssuesEnabled", "hasProjectsEnabled",
+		"hasWikiEnabled", "homepageUrl", "id", "isArchived", "isBlankIssuesEnabled", "isEmpty",
+		"isFork", "isInOrganization", "isMirror", "isPrivate", "isSecurityPolicyEnabled",
+		"isTemplate", "isUserConfigurationRepository", "issueTemplates", "issues", "labels",
+		"languages", "latestRelease", "licenseInfo", "mentionableUsers", "mergeCommitAllowed",
+		"milestones", "mirrorUrl", "name", "nameWithOwner", "openGraphImageUrl", "owner",
+		"parent", "primaryLanguage", "projects", "projectsV2", "pullRequestTemplates",
+		"pullRequests", "pushedAt", "rebaseMergeAllowed", "repositoryTopics",
+		"securityPolicyUrl", "squashMergeAllowed", "sshUrl", "stargazerCount",
+		"templateRepository", "updatedAt", "url", "usesCustomOpenGraphImage",
+		"viewerCanAdminister", "viewerDefaultCommitEmail", "viewerDefaultMergeMethod",
+		"viewerHasStarred", "viewerPermission", "viewerPossibleCommitEmails",
+		"viewerSubscription", "visibility", "watchers",
+	}
+
+	runListFields = []string{
+		"attempt", "conclusion", "createdAt", "databaseId", "displayTitle", "event",
+		"headBranch", "headSha", "name", "number", "startedAt", "status", "updatedAt", "url",
+		"workflowDatabaseId", "workflowName",
+	}
+
+	runViewFields = append(slices.Clone(runListFields), "jobs")
+)
+
+// selectFields returns the --json argument for a call: defaults when
+// nothing was requested, otherwise the requested fields once each, after
+// checking gh supports them.
+func selectFields(requested, supported []string, defaults string) (string, error) {
+	if len(requested) == 0 {
+		return defaults, nil
+	}
+
+	var fields []string
+
+	for _, f := range requested {
+		if !slices.Contains(supported, f) {
+			return "", fmt.Errorf("unsupported field %q (supported: %s)", f, strings.Join(supported, ", "))
+		}
+
+		if !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+
+	return strings.Join(fields, ","), nil
+}
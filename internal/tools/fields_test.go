@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFieldsHaveResultTypes checks every field a call can request through
+// fields has a place in the tool's result type, so the lists in fields.go
+// and the types in results.go cannot drift apart.
+func TestFieldsHaveResultTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		typ    reflect.Type
+	}{
+		{"issue", issueFields, reflect.TypeFor[Issue]()},
+		{"pr", prFields, reflect.TypeFor[PullRequest]()},
+		{"repo", repoFields, reflect.TypeFor[Repository]()},
+		{"run list", runListFields, reflect.TypeFor[Run]()},
+		{"run view", runViewFields, reflect.TypeFor[Run]()},
+	}
+
+	for _, tt := range tests {
+		tags := make(map[string]bool)
+
+		for i := range tt.typ.NumField() {
+			name, _, _ := strings.Cut(tt.typ.Field(i).Tag.Get("json"), ",")
+			tags[name] = true
+		}
+
+		for _, field := range tt.fields {
+			if !tags[field] {
+				t.Errorf("%s field %s has no JSON tag in %s", tt.name, field, tt.typ.Name())
+			}
+		}
+	}
+}
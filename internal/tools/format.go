@@ -0,0 +1,404 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// Output formats. json leaves results as gh printed them; the others only
+// apply to JSON output, so logs and file contents pass through unchanged.
+const (
+	FormatJSON    = "json"
+	FormatCompact = "compact"
+	FormatTable   = "table"
+	FormatLines   = "lines"
+	FormatYAML    = "yaml"
+	FormatTOON    = "toon"
+)
+
+var outputFormats = []string{FormatJSON, FormatCompact, FormatTable, FormatLines, FormatYAML, FormatTOON}
+
+const outputFormatDescription = "Result format: json (as returned), compact (minified JSON), table (markdown table for lists), lines (one tab-separated line per list entry), yaml, or toon (Token-Oriented Object Notation: indented like YAML, with lists of like objects as one header and a comma-separated row each)"
+
+// outputColumns limits the columns of table and lines output, for tools
+// whose entries carry fields that are rarely worth the tokens.
+func outputColumns(columns ...string) toolOption {
+	return func(t *tool) {
+		t.columns = columns
+	}
+}
+
+func addOutputFormatProperty(schema json.RawMessage, defaultFormat string) json.RawMessage {
+	var s map[string]any
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return schema
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	if properties == nil {
+		properties = make(map[string]any)
+		s["properties"] = properties
+	}
+
+	properties["output_format"] = map[string]any{
+		"type":        "string",
+		"enum":        outputFormats,
+		"description": outputFormatDescription + "; defaults to " + defaultFormat,
+	}
+
+	out, err := json.Marshal(s)
+	if err != nil {
+		return schema
+	}
+
+	return out
+}
+
+// withOutputFormat renders successful results in the call's output_format,
+// or defaultFormat when the call does not pick one.
+func withOutputFormat(defaultFormat string, columns []string, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		format := defaultFormat
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(args, &fields); err == nil {
+			if raw, ok := fields["output_format"]; ok {
+				var requested string
+				_ = json.Unmarshal(raw, &requested)
+				delete(fields, "output_format")
+
+				if requested != "" {
+					if !slices.Contains(outputFormats, requested) {
+						return errorResult(gh.KindValidation, "unknown output_format %q (supported: %s)", requested, strings.Join(outputFormats, ", ")), nil
+					}
+
+					format = requested
+				}
+
+				if args, err = json.Marshal(fields); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		result, err := handler(ctx, args)
+		if err != nil || result == nil || result.IsError || format == FormatJSON {
+			return result, err
+		}
+
+		for i := range result.Content {
+			result.Content[i].Text = formatOutput(result.Content[i].Text, format, columns)
+		}
+
+		return result, nil
+	}
+}
+
+func formatOutput(text, format string, columns []string) string {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+
+	v, err := decodeOrdered(dec)
+	if err != nil || dec.More() {
+		// Not a single JSON document: logs, file contents, jq output.
+		return text
+	}
+
+	switch format {
+	case FormatCompact:
+		return compactJSON(v)
+	case FormatYAML:
+		return yamlDocument(v)
+	case FormatTOON:
+		return toonDocument(v)
+	}
+
+	rows, footer := listRows(v)
+	if rows == nil {
+		return compactJSON(v)
+	}
+
+	if len(columns) == 0 {
+		for _, row := range rows {
+			for _, k := range row.keys {
+				if !slices.Contains(columns, k) {
+					columns = append(columns, k)
+				}
+			}
+		}
+	}
+
+	// A table needs a row to show and a column to show it in.
+	switch {
+	case len(rows) == 0:
+		return "(no results)\n" + footer
+	case len(columns) == 0:
+		return compactJSON(v)
+	}
+
+	var out string
+	if format == FormatTable {
+		out = markdownTable(rows, columns)
+	} else {
+		out = tabLines(rows, columns)
+	}
+
+	return out + footer
+}
+
+// listRows returns the entries of a list result: a top-level array of
+// objects, or the entries of a paginated envelope, whose other fields
+// become a footer.
+func listRows(v any) ([]*orderedObject, string) {
+	if envelope, ok := v.(*orderedObject); ok {
+		entries, ok := envelope.values["entries"]
+		if !ok {
+			return nil, ""
+		}
+
+		rows, _ := listRows(entries)
+		if rows == nil {
+			return nil, ""
+		}
+
+		var footer []string
+		for _, k := range envelope.keys {
+			if k != "entries" {
+				footer = append(footer, fmt.Sprintf("%s: %s", k, cell(envelope.values[k])))
+			}
+		}
+
+		return rows, "\n" + strings.Join(footer, ", ") + "\n"
+	}
+
+	items, ok := v.([]any)
+	if !ok {
+		return nil, ""
+	}
+
+	rows := make([]*orderedObject, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(*orderedObject)
+		if !ok {
+			return nil, ""
+		}
+
+		rows = append(rows, obj)
+	}
+
+	return rows, ""
+}
+
+func markdownTable(rows []*orderedObject, columns []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+
+	escape := strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = escape.Replace(cell(row.values[c]))
+		}
+
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	return sb.String()
+}
+
+func tabLines(rows []*orderedObject, columns []string) string {
+	var sb strings.Builder
+
+	sb.WriteString(strings.Join(columns, "\t") + "\n")
+
+	escape := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = escape.Replace(cell(row.values[c]))
+		}
+
+		sb.WriteString(strings.Join(cells, "\t") + "\n")
+	}
+
+	return sb.String()
+}
+
+// cell renders a value for a table cell. Objects with a single field,
+// such as {"login": "alice"}, collapse to that field, and lists of
+// scalars to a comma-separated list; anything else stays compact JSON.
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case *orderedObject:
+		if len(v.keys) == 1 {
+			if s, ok := scalarCell(v.values[v.keys[0]]); ok {
+				return s
+			}
+		}
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			s, ok := scalarCell(item)
+			if !ok {
+				return compactJSON(v)
+			}
+
+			parts[i] = s
+		}
+
+		return strings.Join(parts, ", ")
+	}
+
+	return compactJSON(v)
+}
+
+func scalarCell(v any) (string, bool) {
+	switch v := v.(type) {
+	case []any:
+		return "", false
+	case *orderedObject:
+		if len(v.keys) != 1 {
+			return "", false
+		}
+
+		return scalarCell(v.values[v.keys[0]])
+	}
+
+	return cell(v), true
+}
+
+// orderedObject is a JSON object that remembers its key order, so
+// reformatted output lists fields the way gh printed them.
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := &orderedObject{values: make(map[string]any)}
+
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			key, _ := keyTok.(string)
+
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, seen := obj.values[key]; !seen {
+				obj.keys = append(obj.keys, key)
+			}
+
+			obj.values[key] = value
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+
+		return obj, nil
+
+	case json.Delim('['):
+		items := []any{}
+
+		for dec.More() {
+			item, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+
+		return items, nil
+	}
+
+	return tok, nil
+}
+
+// compactJSON re-encodes a decoded value without whitespace, keeping key
+// order and leaving <, > and & unescaped as gh prints them.
+func compactJSON(v any) string {
+	var buf bytes.Buffer
+	writeJSON(&buf, v)
+
+	return buf.String()
+}
+
+func writeJSON(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		buf.WriteString(v.String())
+	case string:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(v)
+		buf.Truncate(buf.Len() - 1) // Encode appends a newline.
+	case []any:
+		buf.WriteByte('[')
+
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			writeJSON(buf, item)
+		}
+
+		buf.WriteByte(']')
+	case *orderedObject:
+		buf.WriteByte('{')
+
+		for i, k := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			writeJSON(buf, k)
+			buf.WriteByte(':')
+			writeJSON(buf, v.values[k])
+		}
+
+		buf.WriteByte('}')
+	}
+}
@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// toonDocument renders a decoded JSON value as TOON (Token-Oriented Object
+// Notation): YAML-like indentation for objects, and arrays declared with
+// their length, inline when they hold scalars and as a table with one
+// header when they hold objects of the same scalar fields. Key order is
+// kept and values are comma-delimited.
+func toonDocument(v any) string {
+	var sb strings.Builder
+	writeTOON(&sb, 0, "", v)
+
+	return sb.String()
+}
+
+// writeTOON writes v at an indent level of two spaces, as the value of key
+// or, with no key, as a top-level value or list item.
+func writeTOON(sb *strings.Builder, indent int, key string, v any) {
+	pad := strings.Repeat("  ", indent)
+
+	switch v := v.(type) {
+	case *orderedObject:
+		fieldIndent := indent
+		if key != "" {
+			sb.WriteString(pad + key + ":\n")
+			fieldIndent++
+		}
+
+		for _, k := range v.keys {
+			writeTOON(sb, fieldIndent, toonKey(k), v.values[k])
+		}
+
+	case []any:
+		header := pad + key + "[" + strconv.Itoa(len(v)) + "]"
+
+		if cells, ok := toonInline(v); ok {
+			sb.WriteString(header + ":")
+			if len(cells) > 0 {
+				sb.WriteString(" " + strings.Join(cells, ","))
+			}
+
+			sb.WriteString("\n")
+
+			return
+		}
+
+		if fields, ok := toonTabular(v); ok {
+			names := make([]string, len(fields))
+			for i, f := range fields {
+				names[i] = toonKey(f)
+			}
+
+			sb.WriteString(header + "{" + strings.Join(names, ",") + "}:\n")
+
+			for _, item := range v {
+				row := item.(*orderedObject)
+
+				cells := make([]string, len(fields))
+				for i, f := range fields {
+					cells[i] = toonScalar(row.values[f])
+				}
+
+				sb.WriteString(pad + "  " + strings.Join(cells, ",") + "\n")
+			}
+
+			return
+		}
+
+		sb.WriteString(header + ":\n")
+
+		for _, item := range v {
+			writeTOONItem(sb, indent+1, item)
+		}
+
+	default:
+		if key == "" {
+			sb.WriteString(pad + toonScalar(v) + "\n")
+		} else {
+			sb.WriteString(pad + key + ": " + toonScalar(v) + "\n")
+		}
+	}
+}
+
+// writeTOONItem writes an item of an expanded list: the item is laid out
+// one level deeper and its first line takes the "- " marker in place of
+// indentation.
+func writeTOONItem(sb *strings.Builder, indent int, v any) {
+	pad := strings.Repeat("  ", indent)
+
+	if obj, ok := v.(*orderedObject); ok && len(obj.keys) == 0 {
+		sb.WriteString(pad + "-\n")
+		return
+	}
+
+	var item strings.Builder
+	writeTOON(&item, indent+1, "", v)
+
+	sb.WriteString(pad + "- " + strings.TrimPrefix(item.String(), pad+"  "))
+}
+
+// toonInline returns the cells of an array of scalars.
+func toonInline(items []any) ([]string, bool) {
+	cells := make([]string, len(items))
+
+	for i, item := range items {
+		switch item.(type) {
+		case *orderedObject, []any:
+			return nil, false
+		}
+
+		cells[i] = toonScalar(item)
+	}
+
+	return cells, true
+}
+
+// toonTabular returns the fields of an array of objects that all have the
+// same fields, in the first object's order, holding scalars only.
+func toonTabular(items []any) ([]string, bool) {
+	first, ok := items[0].(*orderedObject)
+	if !ok || len(first.keys) == 0 {
+		return nil, false
+	}
+
+	for _, item := range items {
+		obj, ok := item.(*orderedObject)
+		if !ok || len(obj.keys) != len(first.keys) {
+			return nil, false
+		}
+
+		for _, k := range first.keys {
+			value, ok := obj.values[k]
+			if !ok {
+				return nil, false
+			}
+
+			switch value.(type) {
+			case *orderedObject, []any:
+				return nil, false
+			}
+		}
+	}
+
+	return first.keys, true
+}
+
+var (
+	toonBareKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+	// toonNumeric are strings a TOON reader would take for numbers.
+	toonNumeric = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$|^0[0-9]+$`)
+)
+
+func toonKey(k string) string {
+	if toonBareKey.MatchString(k) {
+		return k
+	}
+
+	return compactJSON(k)
+}
+
+// toonScalar renders a scalar, quoting strings that would otherwise read as
+// another type, break the line or split at a delimiter.
+func toonScalar(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		if v == nil {
+			return "null"
+		}
+
+		return cell(v)
+	}
+
+	if toonBare(s) {
+		return s
+	}
+
+	return compactJSON(s)
+}
+
+func toonBare(s string) bool {
+	switch {
+	case s == "", strings.TrimSpace(s) != s, s == "true", s == "false", s == "null":
+		return false
+	case toonNumeric.MatchString(s), strings.HasPrefix(s, "-"):
+		return false
+	case strings.ContainsAny(s, ",:\"\\[]{}#"):
+		return false
+	}
+
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
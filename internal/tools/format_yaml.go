@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// yamlDocument renders a decoded JSON value as block-style YAML, keeping
+// key order. Strings that YAML would read as something else are
+// double-quoted, and multi-line strings become literal blocks.
+func yamlDocument(v any) string {
+	var sb strings.Builder
+	writeYAML(&sb, 0, v)
+
+	return sb.String()
+}
+
+// writeYAML writes v, a top-level value or the value of a block mapping
+// entry or sequence item, at indent levels of two spaces.
+func writeYAML(sb *strings.Builder, indent int, v any) {
+	pad := strings.Repeat("  ", indent)
+
+	switch v := v.(type) {
+	case *orderedObject:
+		if len(v.keys) == 0 {
+			sb.WriteString(pad + "{}\n")
+			return
+		}
+
+		for _, k := range v.keys {
+			sb.WriteString(pad + yamlKey(k) + ":")
+			writeYAMLValue(sb, indent, v.values[k])
+		}
+
+	case []any:
+		if len(v) == 0 {
+			sb.WriteString(pad + "[]\n")
+			return
+		}
+
+		for _, item := range v {
+			writeYAMLItem(sb, indent, item)
+		}
+
+	default:
+		sb.WriteString(pad + yamlScalar(v, indent) + "\n")
+	}
+}
+
+// writeYAMLValue writes the value of a mapping entry whose key and colon
+// are already written.
+func writeYAMLValue(sb *strings.Builder, indent int, v any) {
+	switch v := v.(type) {
+	case *orderedObject:
+		if len(v.keys) == 0 {
+			sb.WriteString(" {}\n")
+			return
+		}
+
+		sb.WriteString("\n")
+		writeYAML(sb, indent+1, v)
+
+	case []any:
+		if len(v) == 0 {
+			sb.WriteString(" []\n")
+			return
+		}
+
+		sb.WriteString("\n")
+		writeYAML(sb, indent+1, v)
+
+	default:
+		sb.WriteString(" " + yamlScalar(v, indent+1) + "\n")
+	}
+}
+
+// writeYAMLItem writes a sequence item: the item is laid out one level
+// deeper and its first line takes the "- " marker in place of indentation.
+func writeYAMLItem(sb *strings.Builder, indent int, v any) {
+	var item strings.Builder
+	writeYAML(&item, indent+1, v)
+
+	pad := strings.Repeat("  ", indent)
+	sb.WriteString(pad + "- " + strings.TrimPrefix(item.String(), pad+"  "))
+}
+
+var (
+	// yamlReserved are plain scalars YAML 1.1 or 1.2 resolve to something
+	// other than a string, such as booleans, numbers and timestamps.
+	yamlReserved = regexp.MustCompile(`(?i)^(~|null|true|false|yes|no|on|off|y|n|[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([t ].*)?|[-+]?(\.inf|\.nan)|[-+]?[0-9][0-9_]*(\.[0-9_]*)?([eE][-+]?[0-9]+)?|[-+]?\.[0-9_]+([eE][-+]?[0-9]+)?|0x[0-9a-f_]+|0o?[0-7_]+|[0-9]+(:[0-9]{2})+)$`)
+
+	// yamlPlainStart are characters a plain scalar cannot start with.
+	yamlPlainStart = "-?:,[]{}#&*!|>'\"%@`"
+)
+
+func yamlKey(k string) string {
+	if yamlPlain(k) {
+		return k
+	}
+
+	return compactJSON(k)
+}
+
+// yamlScalar renders a scalar at the given indent level, which a literal
+// block indents its lines to.
+func yamlScalar(v any, indent int) string {
+	s, ok := v.(string)
+	if !ok {
+		if v == nil {
+			return "null"
+		}
+
+		return cell(v)
+	}
+
+	if yamlPlain(s) {
+		return s
+	}
+
+	if literal, ok := yamlLiteral(s, indent); ok {
+		return literal
+	}
+
+	return compactJSON(s)
+}
+
+// yamlPlain reports whether s can be written unquoted and still read back
+// as the same string.
+func yamlPlain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || yamlReserved.MatchString(s) {
+		return false
+	}
+
+	if strings.ContainsAny(s[:1], yamlPlainStart) {
+		return false
+	}
+
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+
+	for _, r := range s {
+		if r < ' ' || r == 0x7f || r == '\ufeff' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// yamlLiteral renders a multi-line string as a literal block, whose
+// chomping indicator keeps its trailing newlines exact. Strings a block
+// cannot hold, such as those with carriage returns or a leading space, and
+// top-level strings, whose lines could read as document markers, are left
+// to quoting.
+func yamlLiteral(s string, indent int) (string, bool) {
+	if indent == 0 || !strings.Contains(s, "\n") || strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\n") {
+		return "", false
+	}
+
+	for _, r := range s {
+		if (r < ' ' && r != '\n' && r != '\t') || r == 0x7f || r == '\ufeff' {
+			return "", false
+		}
+	}
+
+	body := strings.TrimRight(s, "\n")
+	trailing := len(s) - len(body)
+
+	// Clip (|) ends the block with one newline and strip (|-) with none;
+	// keep (|+) needs the newlines past the first written out.
+	header := "|-"
+	switch {
+	case trailing == 1:
+		header = "|"
+	case trailing > 1:
+		header = "|+"
+		body = s[:len(s)-1]
+	}
+
+	pad := strings.Repeat("  ", indent)
+
+	var sb strings.Builder
+	sb.WriteString(header)
+
+	for _, line := range strings.Split(body, "\n") {
+		sb.WriteString("\n")
+
+		if line != "" {
+			sb.WriteString(pad + line)
+		}
+	}
+
+	return sb.String(), true
+}
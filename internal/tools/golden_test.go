@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/annotate"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/gh/ghtest"
+	"github.com/friedenberg/get-hubbed/internal/redact"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// goldenCase is a tool call plus the gh invocations it is expected to make.
+// Each case lives in testdata/golden/<tool>/<case>.json next to the
+// <case>.golden file holding the argv and result it produced.
+type goldenCase struct {
+	Arguments json.RawMessage `json:"arguments"`
+	GH        []gh.Invocation `json:"gh"`
+}
+
+func TestGolden(t *testing.T) {
+	// Redaction is on by default in the server, so goldens show output as
+	// clients see it.
+	r := newToolRegistry(Options{Redactor: redact.New(nil), Hosts: []string{"ghe.example.com"}})
+	registerTools(r)
+
+	for _, tl := range r.tools {
+		t.Run(tl.name, func(t *testing.T) {
+			paths, err := filepath.Glob(filepath.Join("testdata", "golden", tl.name, "*.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(paths) == 0 {
+				t.Fatalf("no golden cases in testdata/golden/%s", tl.name)
+			}
+
+			for _, path := range paths {
+				name := strings.TrimSuffix(filepath.Base(path), ".json")
+
+				t.Run(name, func(t *testing.T) {
+					runGoldenCase(t, tl, path)
+				})
+			}
+		})
+	}
+}
+
+func runGoldenCase(t *testing.T, tl tool, path string) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c goldenCase
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+
+	fake := ghtest.NewFake(c.GH...)
+	ghtest.Install(t, fake)
+
+	result, err := tl.handler(context.Background(), c.Arguments)
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	for _, args := range fake.Unmatched() {
+		t.Errorf("unrecorded gh invocation: %q", args)
+	}
+
+	if !reshapedOutputs[tl.name] {
+		checkStructuredMatchesText(t, result)
+	}
+
+	got := renderGolden(t, fake.Calls(), fake.Inputs(), result)
+	goldenPath := strings.TrimSuffix(path, ".json") + ".golden"
+
+	if *update {
+		if err := os.WriteFile(goldenPath, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("output mismatch for %s (run with -update to accept)\n--- want\n%s\n--- got\n%s", goldenPath, want, got)
+	}
+}
+
+// reshapedOutputs are the tools whose structured content folds the
+// different shapes their text takes into one.
+var reshapedOutputs = map[string]bool{
+	"content_blame": true,
+	"content_tree":  true,
+}
+
+// checkStructuredMatchesText fails when a result's structured content
+// differs from its JSON text in any field, so clients reading either see
+// the same data. Text in other formats, such as tables, is not compared.
+func checkStructuredMatchesText(t *testing.T, result *protocol.ToolCallResult) {
+	t.Helper()
+
+	var text, structured strings.Builder
+
+	for _, block := range result.Content {
+		if block.Type == annotate.StructuredContentType {
+			structured.WriteString(block.Text)
+		} else {
+			text.WriteString(block.Text)
+		}
+	}
+
+	if structured.Len() == 0 {
+		return
+	}
+
+	var fromText, fromStructured any
+	if err := json.Unmarshal([]byte(text.String()), &fromText); err != nil {
+		return
+	}
+
+	if err := json.Unmarshal([]byte(structured.String()), &fromStructured); err != nil {
+		t.Fatalf("structured content is not JSON: %v", err)
+	}
+
+	if list, ok := fromText.([]any); ok {
+		fromText = map[string]any{"items": list}
+	}
+
+	if !reflect.DeepEqual(fromText, fromStructured) {
+		t.Errorf("structured content differs from the text result\n--- text\n%s\n--- structured\n%s", text.String(), structured.String())
+	}
+}
+
+func renderGolden(t *testing.T, calls [][]string, inputs []string, result *protocol.ToolCallResult) string {
+	var sb strings.Builder
+
+	sb.WriteString("# gh\n")
+
+	for i, args := range calls {
+		encoded, err := json.Marshal(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sb.Write(encoded)
+		sb.WriteString("\n")
+
+		// Request bodies sent as JSON on stdin follow their call.
+		if input := inputs[i]; input != "" {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, []byte(input)); err != nil {
+				compact.Reset()
+				compact.WriteString(input)
+			}
+
+			sb.WriteString("< ")
+			sb.Write(compact.Bytes())
+			sb.WriteString("\n")
+		}
+	}
+
+	if result.IsError {
+		sb.WriteString("# error\n")
+	} else {
+		sb.WriteString("# result\n")
+	}
+
+	for _, block := range result.Content {
+		if block.Type == annotate.StructuredContentType {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, []byte(block.Text), "", "  "); err != nil {
+				t.Fatalf("structured content is not JSON: %v", err)
+			}
+
+			sb.WriteString("# structured\n")
+			sb.WriteString(indented.String())
+			sb.WriteString("\n")
+
+			continue
+		}
+
+		sb.WriteString(block.Text)
+
+		if !strings.HasSuffix(block.Text, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
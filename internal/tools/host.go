@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+const hostDescription = "GitHub host to call, one of the hosts the server is configured for; a repo given as HOST/OWNER/REPO also selects its host"
+
+// addHostProperty adds the host parameter every tool accepts, limited to
+// hosts.
+func addHostProperty(schema json.RawMessage, defaultHost string, hosts []string) json.RawMessage {
+	var s map[string]any
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return schema
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	if properties == nil {
+		properties = make(map[string]any)
+		s["properties"] = properties
+	}
+
+	description := hostDescription
+	if defaultHost != "" {
+		description += "; defaults to " + defaultHost
+	}
+
+	properties["host"] = map[string]any{
+		"type":        "string",
+		"description": description,
+		"enum":        hosts,
+	}
+
+	out, err := json.Marshal(s)
+	if err != nil {
+		return schema
+	}
+
+	return out
+}
+
+// splitHostRepo splits HOST/OWNER/REPO into its host and OWNER/REPO.
+// Anything else is returned unchanged with an empty host.
+func splitHostRepo(repo string) (host, ownerRepo string) {
+	if strings.Count(repo, "/") != 2 {
+		return "", repo
+	}
+
+	host, ownerRepo, _ = strings.Cut(repo, "/")
+
+	return host, ownerRepo
+}
+
+// withHost resolves the host a call targets, from its host parameter, a
+// HOST/OWNER/REPO repo or defaultHost, and runs the handler with that host,
+// normalized, on the context and the repo reduced to OWNER/REPO. A named
+// host that is not one of hosts is refused before the handler runs, so no
+// client for it is built and no token sent to it.
+func withHost(defaultHost string, hosts []string, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(args, &fields); err != nil {
+			// Let the handler report malformed arguments.
+			return handler(gh.WithHost(ctx, defaultHost), args)
+		}
+
+		var host, repo string
+
+		if raw, ok := fields["host"]; ok {
+			_ = json.Unmarshal(raw, &host)
+			delete(fields, "host")
+		}
+
+		if raw, ok := fields["repo"]; ok {
+			_ = json.Unmarshal(raw, &repo)
+		}
+
+		if repoHost, ownerRepo := splitHostRepo(repo); repoHost != "" {
+			if host != "" && gh.NormalizeHost(host) != gh.NormalizeHost(repoHost) {
+				return errorResult(gh.KindValidation, "repo %s names host %s but host is %s", repo, repoHost, host), nil
+			}
+
+			host = repoHost
+			fields["repo"], _ = json.Marshal(ownerRepo)
+		}
+
+		if host == "" {
+			host = defaultHost
+		} else if host = gh.NormalizeHost(host); !slices.Contains(hosts, host) {
+			return errorResult(gh.KindValidation, "host %s is not configured; calls may name %s", host, strings.Join(hosts, ", ")), nil
+		}
+
+		rewritten, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(gh.WithHost(ctx, gh.NormalizeHost(host)), rewritten)
+	}
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/gh/ghtest"
+)
+
+func TestWithHost(t *testing.T) {
+	var gotHost, gotRepo string
+
+	handler := func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		var params struct {
+			Repo string `json:"repo"`
+		}
+
+		if err := json.Unmarshal(args, &params); err != nil {
+			t.Fatal(err)
+		}
+
+		gotHost, gotRepo = gh.HostFromContext(ctx), params.Repo
+
+		return protocol.ErrorResult("ok"), nil
+	}
+
+	tests := []struct {
+		name     string
+		args     string
+		wantHost string
+		wantRepo string
+	}{
+		{"server default", `{"repo": "octo/hello"}`, "ghe.example.com", "octo/hello"},
+		{"host parameter", `{"repo": "octo/hello", "host": "github.com"}`, "github.com", "octo/hello"},
+		{"qualified repo", `{"repo": "GitHub.com/octo/hello"}`, "github.com", "octo/hello"},
+		{"api host", `{"repo": "api.github.com/octo/hello"}`, "github.com", "octo/hello"},
+		{"host with port", `{"repo": "octo/hello", "host": "ghe.example.com:443"}`, "ghe.example.com", "octo/hello"},
+	}
+
+	hosts := []string{"ghe.example.com", "github.com"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := withHost("ghe.example.com", hosts, handler)(context.Background(), json.RawMessage(tt.args)); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotHost != tt.wantHost || gotRepo != tt.wantRepo {
+				t.Errorf("got host %q repo %q, want %q %q", gotHost, gotRepo, tt.wantHost, tt.wantRepo)
+			}
+		})
+	}
+
+	for _, args := range []string{
+		`{"repo": "ghe.example.com/octo/hello", "host": "github.com"}`,
+		`{"repo": "octo/hello", "host": "attacker.example"}`,
+		`{"repo": "attacker.example/octo/hello"}`,
+	} {
+		gotHost = ""
+
+		result, err := withHost("", hosts, handler)(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.IsError || gotHost != "" {
+			t.Errorf("args %s: expected the call to be refused", args)
+		}
+	}
+}
+
+func TestUnlistedHostSendsNoRequest(t *testing.T) {
+	fake := ghtest.NewFake()
+	ghtest.Install(t, fake)
+
+	var built []string
+
+	prev := gh.Default()
+	gh.SetDefault(gh.NewHostRouter("", func(ctx context.Context, host string) (gh.Client, error) {
+		built = append(built, host)
+		return gh.ExecClient{}, nil
+	}))
+	t.Cleanup(func() { gh.SetDefault(prev) })
+
+	r := newToolRegistry(Options{Hosts: []string{"ghe.example.com"}})
+	registerTools(r)
+
+	for _, tl := range r.tools {
+		if tl.name != "issue_list" {
+			continue
+		}
+
+		for _, args := range []string{
+			`{"repo": "octo/hello", "host": "attacker.example"}`,
+			`{"repo": "attacker.example/octo/hello"}`,
+			`{"repo": "api.attacker.example:8443/octo/hello"}`,
+		} {
+			result, err := tl.handler(context.Background(), json.RawMessage(args))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.IsError {
+				t.Errorf("args %s: unlisted host was not refused", args)
+			}
+		}
+
+		if calls := fake.Calls(); len(calls) > 0 || len(built) > 0 {
+			t.Errorf("refused calls reached GitHub: gh calls %q, clients built for %q", calls, built)
+		}
+
+		return
+	}
+
+	t.Fatal("issue_list not registered")
+}
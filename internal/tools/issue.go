@@ -4,13 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/amarbel-llc/go-lib-mcp/protocol"
-	"github.com/amarbel-llc/go-lib-mcp/server"
 	"github.com/friedenberg/get-hubbed/internal/gh"
 )
 
-func registerIssueTools(r *server.ToolRegistry) {
+func registerIssueTools(r *toolRegistry) {
 	r.Register(
 		"issue_list",
 		"List issues in a repository",
@@ -19,7 +19,8 @@ func registerIssueTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"state": {
 					"type": "string",
@@ -28,17 +29,27 @@ func registerIssueTools(r *server.ToolRegistry) {
 				},
 				"limit": {
 					"type": "integer",
-					"description": "Maximum number of issues to list (default 30)"
+					"description": "Maximum number of issues to list (default 30)",
+					"minimum": 1
 				},
 				"labels": {
 					"type": "array",
 					"items": {"type": "string"},
 					"description": "Filter by labels"
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh issue list --json supports"
+				},
+				"jq": {
+					"type": "string",
+					"description": "jq expression gh applies to the JSON output before returning it, e.g. '.[] | {number, title}'"
 				}
-			},
-			"required": ["repo"]
+			}
 		}`),
 		handleIssueList,
+		structured[[]Issue](),
 	)
 
 	r.Register(
@@ -49,16 +60,24 @@ func registerIssueTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"number": {
 					"type": "integer",
-					"description": "Issue number"
+					"description": "Issue number",
+					"minimum": 1
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh issue view --json supports"
 				}
 			},
-			"required": ["repo", "number"]
+			"required": ["number"]
 		}`),
 		handleIssueView,
+		structured[Issue](),
 	)
 
 	r.Register(
@@ -69,7 +88,8 @@ func registerIssueTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"title": {
 					"type": "string",
@@ -85,9 +105,11 @@ func registerIssueTools(r *server.ToolRegistry) {
 					"description": "Labels to add"
 				}
 			},
-			"required": ["repo", "title"]
+			"required": ["title"]
 		}`),
 		handleIssueCreate,
+		mutating,
+		structured[CreatedIssue](),
 	)
 }
 
@@ -97,16 +119,23 @@ func handleIssueList(ctx context.Context, args json.RawMessage) (*protocol.ToolC
 		State  string   `json:"state"`
 		Limit  int      `json:"limit"`
 		Labels []string `json:"labels"`
+		Fields []string `json:"fields"`
+		JQ     string   `json:"jq"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	fields, err := selectFields(params.Fields, issueFields, "number,title,state,author,labels,createdAt,updatedAt,url")
+	if err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
 	ghArgs := []string{
 		"issue", "list",
 		"-R", params.Repo,
-		"--json", "number,title,state,author,labels,createdAt,updatedAt,url",
+		"--json", fields,
 	}
 
 	if params.State != "" {
@@ -121,9 +150,13 @@ func handleIssueList(ctx context.Context, args json.RawMessage) (*protocol.ToolC
 		ghArgs = append(ghArgs, "--label", label)
 	}
 
+	if params.JQ != "" {
+		ghArgs = append(ghArgs, "--jq", params.JQ)
+	}
+
 	out, err := gh.Run(ctx, ghArgs...)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh issue list: %v", err)), nil
+		return ghErrorResult("gh issue list", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -135,21 +168,27 @@ func handleIssueList(ctx context.Context, args json.RawMessage) (*protocol.ToolC
 
 func handleIssueView(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
-		Repo   string `json:"repo"`
-		Number int    `json:"number"`
+		Repo   string   `json:"repo"`
+		Number int      `json:"number"`
+		Fields []string `json:"fields"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	fields, err := selectFields(params.Fields, issueFields, "number,title,state,body,author,labels,assignees,comments,createdAt,updatedAt,url")
+	if err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
 	out, err := gh.Run(ctx,
 		"issue", "view", fmt.Sprintf("%d", params.Number),
 		"-R", params.Repo,
-		"--json", "number,title,state,body,author,labels,assignees,comments,createdAt,updatedAt,url",
+		"--json", fields,
 	)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh issue view: %v", err)), nil
+		return ghErrorResult("gh issue view", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -168,31 +207,47 @@ func handleIssueCreate(ctx context.Context, args json.RawMessage) (*protocol.Too
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
-	ghArgs := []string{
-		"issue", "create",
-		"-R", params.Repo,
-		"--title", params.Title,
-	}
+	body := map[string]any{"title": params.Title}
 
 	if params.Body != "" {
-		ghArgs = append(ghArgs, "--body", params.Body)
+		body["body"] = params.Body
 	}
 
-	for _, label := range params.Labels {
-		ghArgs = append(ghArgs, "--label", label)
+	if len(params.Labels) > 0 {
+		body["labels"] = params.Labels
 	}
 
-	out, err := gh.Run(ctx, ghArgs...)
+	resp, err := gh.Default().REST(ctx, gh.Request{
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("repos/%s/issues", params.Repo),
+		Body:   body,
+	})
+	if err != nil {
+		return ghErrorResult("gh api issues", err), nil
+	}
+
+	var issue struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+
+	if err := json.Unmarshal(resp.Body, &issue); err != nil {
+		return errorResult(kindInternal, "parsing issue: %v", err), nil
+	}
+
+	created := CreatedIssue{Number: issue.Number, URL: issue.HTMLURL}
+
+	resultJSON, err := json.MarshalIndent(created, "", "  ")
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh issue create: %v", err)), nil
+		return errorResult(kindInternal, "marshaling issue: %v", err), nil
 	}
 
 	return &protocol.ToolCallResult{
 		Content: []protocol.ContentBlock{
-			protocol.TextContent(out),
+			protocol.TextContent(string(resultJSON)),
 		},
 	}, nil
 }
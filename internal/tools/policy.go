@@ -0,0 +1,340 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/policy"
+)
+
+// withPolicy checks every repository and owner a call names against p
+// before the handler runs. With an allow list, a call that may reach
+// repositories it does not name is refused rather than let through.
+func withPolicy(p *policy.Policy, scope policyScope, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		if err := checkPolicy(p, scope, gh.HostFromContext(ctx), args); err != nil {
+			return errorResult(kindPolicy, "%v", err), nil
+		}
+
+		return handler(ctx, args)
+	}
+}
+
+// policyScope says how a tool's arguments name what it touches.
+type policyScope struct {
+	// targets is set for tools with a repo, owner, endpoint or query
+	// argument; a call to one that names nothing reaches whatever the
+	// credentials can see.
+	targets bool
+	// graphQL is set when query is a GraphQL document rather than search
+	// text.
+	graphQL bool
+}
+
+func policyScopeOf(schema json.RawMessage) policyScope {
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+
+	_ = json.Unmarshal(schema, &s)
+
+	var scope policyScope
+
+	for _, name := range []string{"repo", "owner", "endpoint", "query"} {
+		if _, ok := s.Properties[name]; ok {
+			scope.targets = true
+		}
+	}
+
+	_, scope.graphQL = s.Properties["variables"]
+
+	return scope
+}
+
+// policyTargets are the repositories and owners a call names, and
+// descriptions of the parts of it that may reach others.
+type policyTargets struct {
+	repos   []string
+	owners  []string
+	unknown []string
+}
+
+func checkPolicy(p *policy.Policy, scope policyScope, host string, args json.RawMessage) error {
+	targets, err := extractPolicyTargets(scope, args)
+	if err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+
+	for _, repo := range targets.repos {
+		if err := p.CheckRepo(host, repo); err != nil {
+			return err
+		}
+	}
+
+	for _, owner := range targets.owners {
+		if err := p.CheckOwner(host, owner); err != nil {
+			return err
+		}
+	}
+
+	if !p.Restricted() {
+		return nil
+	}
+
+	if len(targets.unknown) > 0 {
+		return fmt.Errorf("policy: cannot tell which repositories %s reaches; with an allow list such calls are refused",
+			strings.Join(targets.unknown, ", "))
+	}
+
+	return nil
+}
+
+// extractPolicyTargets finds the repositories and owners named by tool
+// arguments: the repo and owner parameters, repos/ orgs/ and users/ REST
+// endpoints, search qualifiers, and GraphQL repository(owner, name) and
+// login arguments. REST endpoints outside those, searches without a
+// qualifier and GraphQL fields other than repository, repositoryOwner,
+// organization, user and a qualified search, such as viewer, node or any
+// mutation, are recorded as unknown, as are fields at any depth in
+// crossRepositoryFields.
+func extractPolicyTargets(scope policyScope, args json.RawMessage) (policyTargets, error) {
+	var params struct {
+		Repo      string                     `json:"repo"`
+		Owner     string                     `json:"owner"`
+		Endpoint  string                     `json:"endpoint"`
+		Params    map[string]string          `json:"params"`
+		Query     string                     `json:"query"`
+		Variables map[string]json.RawMessage `json:"variables"`
+	}
+
+	var targets policyTargets
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		// Let the handler report malformed arguments.
+		return targets, nil
+	}
+
+	if params.Repo != "" {
+		targets.repos = append(targets.repos, params.Repo)
+	}
+
+	if params.Owner != "" {
+		targets.owners = append(targets.owners, params.Owner)
+	}
+
+	if params.Endpoint != "" {
+		if err := targets.addEndpoint(params.Endpoint, params.Params["q"]); err != nil {
+			return targets, err
+		}
+	}
+
+	variables := make(map[string]string)
+	for name, raw := range params.Variables {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			variables[name] = s
+			targets.addSearchQualifiers(s)
+		}
+	}
+
+	if params.Query != "" {
+		targets.addSearchQualifiers(params.Query)
+
+		if scope.graphQL {
+			if err := targets.addGraphQL(params.Query, variables); err != nil {
+				return targets, err
+			}
+		}
+	}
+
+	// An endpoint is accounted for above even when it names nothing, such
+	// as rate_limit; other calls that name nothing reach whatever the
+	// credentials can see.
+	if scope.targets && params.Endpoint == "" && len(targets.repos)+len(targets.owners)+len(targets.unknown) == 0 {
+		targets.unknown = append(targets.unknown, "a call naming no repository or owner")
+	}
+
+	return targets, nil
+}
+
+// repositoryFreeEndpoints are the first segments of REST endpoints that
+// return no repository data.
+var repositoryFreeEndpoints = map[string]bool{
+	"codes_of_conduct": true,
+	"emojis":           true,
+	"gitignore":        true,
+	"licenses":         true,
+	"meta":             true,
+	"octocat":          true,
+	"rate_limit":       true,
+	"versions":         true,
+	"zen":              true,
+}
+
+func (t *policyTargets) addEndpoint(endpoint, q string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("cannot parse endpoint %q", endpoint)
+	}
+
+	// A .. segment would be checked as one repository and resolved to
+	// another.
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == ".." {
+			return fmt.Errorf("endpoint %q has a .. segment", endpoint)
+		}
+	}
+
+	qualifiers := t.addSearchQualifiers(q)
+	if q := u.Query().Get("q"); q != "" {
+		qualifiers += t.addSearchQualifiers(q)
+	}
+
+	cleaned := strings.Trim(path.Clean("/"+u.Path), "/")
+	segments := strings.Split(cleaned, "/")
+
+	switch {
+	case segments[0] == "repos" && len(segments) >= 3:
+		t.repos = append(t.repos, segments[1]+"/"+segments[2])
+	case segments[0] == "repos":
+		return fmt.Errorf("cannot determine repository for endpoint %q", endpoint)
+	case segments[0] == "repositories":
+		return fmt.Errorf("endpoint %q addresses a repository by ID; use repos/OWNER/REPO", endpoint)
+	case (segments[0] == "orgs" || segments[0] == "users") && len(segments) >= 2:
+		t.owners = append(t.owners, segments[1])
+	case segments[0] == "search":
+		if qualifiers == 0 {
+			t.unknown = append(t.unknown, fmt.Sprintf("endpoint %s without a repo:, org: or user: qualifier", cleaned))
+		}
+	case repositoryFreeEndpoints[segments[0]]:
+	default:
+		t.unknown = append(t.unknown, "endpoint "+cleaned)
+	}
+
+	return nil
+}
+
+var searchQualifierPattern = regexp.MustCompile(`(?i)(?:^|[\s"(])(repo|org|user):("[^"]*"|[^\s")]+)`)
+
+// addSearchQualifiers adds the repo:, org: and user: qualifiers of a
+// search query and returns how many there were.
+func (t *policyTargets) addSearchQualifiers(q string) int {
+	matches := searchQualifierPattern.FindAllStringSubmatch(q, -1)
+
+	for _, m := range matches {
+		value := strings.Trim(m[2], `"`)
+
+		if strings.EqualFold(m[1], "repo") {
+			t.repos = append(t.repos, value)
+		} else {
+			t.owners = append(t.owners, value)
+		}
+	}
+
+	return len(matches)
+}
+
+var (
+	graphQLCallPattern  = regexp.MustCompile(`\b(repository|repositoryOwner|organization|user)\s*\(([^)]*)\)`)
+	graphQLArgPattern   = regexp.MustCompile(`\b(owner|name|login)\s*:\s*(?:"((?:[^"\\]|\\.)*)"|\$(\w+))`)
+	graphQLQueryPattern = regexp.MustCompile(`\bquery\s*:\s*(?:"((?:[^"\\]|\\.)*)"|\$(\w+))`)
+)
+
+// crossRepositoryFields are GraphQL connections that lead from a checked
+// repository or owner to others: forks and their parents, a repository's
+// owner and everything it owns, templates, and objects by ID.
+var crossRepositoryFields = map[string]bool{
+	"forks":              true,
+	"node":               true,
+	"nodes":              true,
+	"owner":              true,
+	"parent":             true,
+	"repositories":       true,
+	"templateRepository": true,
+}
+
+func (t *policyTargets) addGraphQL(query string, variables map[string]string) error {
+	for _, call := range graphQLCallPattern.FindAllStringSubmatch(query, -1) {
+		field, callArgs := call[1], call[2]
+
+		values := make(map[string]string)
+		for _, arg := range graphQLArgPattern.FindAllStringSubmatch(callArgs, -1) {
+			if arg[3] != "" {
+				v, ok := variables[arg[3]]
+				if !ok {
+					return fmt.Errorf("cannot resolve $%s in %s(...)", arg[3], field)
+				}
+
+				values[arg[1]] = v
+			} else {
+				values[arg[1]] = arg[2]
+			}
+		}
+
+		if field == "repository" {
+			if values["owner"] == "" || values["name"] == "" {
+				return fmt.Errorf("cannot determine repository in %s", call[0])
+			}
+
+			t.repos = append(t.repos, values["owner"]+"/"+values["name"])
+
+			continue
+		}
+
+		if values["login"] == "" {
+			return fmt.Errorf("cannot determine owner in %s", call[0])
+		}
+
+		t.owners = append(t.owners, values["login"])
+	}
+
+	for _, f := range graphQLSelectedFields(query) {
+		// Connections list their items under nodes and edges { node };
+		// only node(id:) and nodes(ids:) look up arbitrary objects.
+		if crossRepositoryFields[f.name] && (f.args != "" || (f.name != "node" && f.name != "nodes")) {
+			t.unknown = append(t.unknown, "GraphQL field "+f.name+", which reaches other repositories")
+		}
+	}
+
+	fields, spread := graphQLRootFields(query)
+	if spread {
+		t.unknown = append(t.unknown, "a fragment spread at the top of the query")
+	}
+
+	for _, f := range fields {
+		switch f.name {
+		case "repository", "repositoryOwner", "organization", "user", "rateLimit", "__typename":
+		case "search":
+			if !searchQualified(f.args, variables) {
+				t.unknown = append(t.unknown, "search without a repo:, org: or user: qualifier")
+			}
+		default:
+			t.unknown = append(t.unknown, "GraphQL field "+f.name)
+		}
+	}
+
+	return nil
+}
+
+// searchQualified reports whether the query argument of a GraphQL search
+// has a repo:, org: or user: qualifier.
+func searchQualified(args string, variables map[string]string) bool {
+	m := graphQLQueryPattern.FindStringSubmatch(args)
+	if m == nil {
+		return false
+	}
+
+	q := m[1]
+	if m[2] != "" {
+		q = variables[m[2]]
+	}
+
+	return searchQualifierPattern.MatchString(q)
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/policy"
+)
+
+func TestPolicyBlocksCallsBeforeHandler(t *testing.T) {
+	p, err := policy.New([]string{"octo/*", "friends/oss-*", "ops/*"}, []string{"octo/secret"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rest := policyScope{targets: true}
+	graphQL := policyScope{targets: true, graphQL: true}
+
+	tests := []struct {
+		name    string
+		scope   policyScope
+		host    string
+		args    string
+		allowed bool
+	}{
+		{"repo", rest, "", `{"repo": "octo/hello"}`, true},
+		{"denied repo", rest, "", `{"repo": "octo/secret"}`, false},
+		{"other owner", rest, "", `{"repo": "customer/app"}`, false},
+		{"other host", rest, "ghe.corp", `{"repo": "octo/hello"}`, false},
+		{"no target", rest, "", `{}`, false},
+		{"owner", rest, "", `{"owner": "ops"}`, true},
+		{"partly denied owner", rest, "", `{"owner": "octo"}`, false},
+		{"foreign owner", rest, "", `{"owner": "customer"}`, false},
+		{"partly allowed owner", rest, "", `{"owner": "friends"}`, false},
+		{"endpoint", rest, "", `{"endpoint": "/repos/octo/hello/releases"}`, true},
+		{"foreign endpoint", rest, "", `{"endpoint": "repos/customer/app/issues"}`, false},
+		{"dot dot endpoint", rest, "", `{"endpoint": "repos/octo/hello/../../customer/app"}`, false},
+		{"org endpoint", rest, "", `{"endpoint": "orgs/customer/members"}`, false},
+		{"repository id endpoint", rest, "", `{"endpoint": "repositories/1234"}`, false},
+		{"user repos endpoint", rest, "", `{"endpoint": "user/repos"}`, false},
+		{"notifications endpoint", rest, "", `{"endpoint": "notifications"}`, false},
+		{"gists endpoint", rest, "", `{"endpoint": "gists"}`, false},
+		{"rate limit endpoint", rest, "", `{"endpoint": "rate_limit"}`, true},
+		{"search endpoint", rest, "", `{"endpoint": "search/issues", "params": {"q": "bug repo:customer/app"}}`, false},
+		{"allowed search endpoint", rest, "", `{"endpoint": "search/issues?q=bug+repo:octo/hello"}`, true},
+		{"unqualified search endpoint", rest, "", `{"endpoint": "search/issues", "params": {"q": "bug"}}`, false},
+		{"search qualifier", rest, "", `{"repo": "octo/hello", "query": "token repo:customer/app"}`, false},
+		{"unqualified search", rest, "", `{"query": "token"}`, false},
+		{"graphql literal", graphQL, "", `{"query": "{ repository(owner: \"octo\", name: \"hello\") { name } }"}`, true},
+		{"graphql foreign literal", graphQL, "", `{"query": "{ repository(owner: \"customer\", name: \"app\") { name } }"}`, false},
+		{"graphql variables", graphQL, "", `{"query": "query($o: String!, $n: String!) { repository(owner: $o, name: $n) { name } }", "variables": {"o": "customer", "n": "app"}}`, false},
+		{"graphql unresolved", graphQL, "", `{"query": "query($o: String!) { repository(owner: $o, name: \"app\") { name } }"}`, false},
+		{"graphql organization", graphQL, "", `{"query": "{ organization(login: \"customer\") { name } }"}`, false},
+		{"graphql viewer", graphQL, "", `{"query": "{ viewer { repositories(first: 10) { nodes { name } } } }"}`, false},
+		{"graphql node", graphQL, "", `{"query": "{ node(id: \"R_1\") { id } }"}`, false},
+		{"graphql nodes", graphQL, "", `{"query": "query($ids: [ID!]!) { nodes(ids: $ids) { id } }", "variables": {"ids": ["R_1"]}}`, false},
+		{"graphql aliased viewer", graphQL, "", `{"query": "{ repository(owner: \"octo\", name: \"hello\") { name } me: viewer { login } }"}`, false},
+		{"graphql search", graphQL, "", `{"query": "query($q: String!) { search(query: $q, type: ISSUE, first: 5) { issueCount } }", "variables": {"q": "bug repo:octo/hello"}}`, true},
+		{"graphql unqualified search", graphQL, "", `{"query": "{ search(query: \"bug\", type: ISSUE, first: 5) { issueCount } }"}`, false},
+		{"graphql mutation", graphQL, "", `{"query": "mutation { addComment(input: {subjectId: \"I_1\", body: \"hi\"}) { clientMutationId } }"}`, false},
+		{"graphql nested connection", graphQL, "", `{"query": "{ repository(owner: \"octo\", name: \"hello\") { issues(first: 5) { nodes { title } edges { node { id } } } } }"}`, true},
+		{"graphql parent", graphQL, "", `{"query": "{ repository(owner: \"octo\", name: \"hello\") { parent { nameWithOwner } } }"}`, false},
+		{"graphql aliased forks", graphQL, "", `{"query": "{ repository(owner: \"octo\", name: \"hello\") { f: forks(first: 5) { nodes { nameWithOwner } } } }"}`, false},
+		{"graphql owner", graphQL, "", `{"query": "{ repository(owner: \"octo\", name: \"hello\") { owner { login } } }"}`, false},
+		{"graphql owner repositories", graphQL, "", `{"query": "{ repositoryOwner(login: \"ops\") { repositories(first: 5) { nodes { name } } } }"}`, false},
+		{"graphql template", graphQL, "", `{"query": "{ repository(owner: \"octo\", name: \"hello\") { ...R } } fragment R on Repository { templateRepository { name } }"}`, false},
+		{"graphql top-level spread", graphQL, "", `{"query": "query { ...everything } fragment everything on Query { viewer { login } }"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := withPolicy(p, tt.scope, func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+				called = true
+				return &protocol.ToolCallResult{}, nil
+			})
+
+			result, err := handler(gh.WithHost(context.Background(), tt.host), json.RawMessage(tt.args))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if called != tt.allowed || result.IsError == tt.allowed {
+				t.Errorf("called = %v, isError = %v; want allowed = %v", called, result.IsError, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestDenyOnlyPolicyAllowsUnknownTargets(t *testing.T) {
+	p, err := policy.New(nil, []string{"octo/secret"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := withPolicy(p, policyScope{targets: true}, func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		return &protocol.ToolCallResult{}, nil
+	})
+
+	for _, args := range []string{`{"endpoint": "user/repos"}`, `{"endpoint": "repos/octo/public/../secret"}`} {
+		result, err := handler(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wantError := strings.Contains(args, ".."); result.IsError != wantError {
+			t.Errorf("%s: isError = %v, want %v", args, result.IsError, wantError)
+		}
+	}
+}
+
+func TestPolicySeesNormalizedHost(t *testing.T) {
+	p, err := policy.New(nil, []string{"octo/secret", "ghe.corp/tools/*"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := withHost("", []string{"ghe.corp", "github.com"}, withPolicy(p, policyScope{targets: true}, func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		t.Errorf("handler ran on %s for %s", gh.HostFromContext(ctx), args)
+		return &protocol.ToolCallResult{}, nil
+	}))
+
+	for _, args := range []string{
+		`{"repo": "api.github.com/octo/secret"}`,
+		`{"repo": "octo/secret", "host": "WWW.GitHub.com."}`,
+		`{"repo": "API.ghe.corp:443/tools/ci"}`,
+	} {
+		result, err := handler(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.IsError {
+			t.Errorf("args %s: denied repository was allowed", args)
+		}
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/amarbel-llc/go-lib-mcp/protocol"
-	"github.com/amarbel-llc/go-lib-mcp/server"
 	"github.com/friedenberg/get-hubbed/internal/gh"
 )
 
-func registerPRTools(r *server.ToolRegistry) {
+func registerPRTools(r *toolRegistry) {
 	r.Register(
 		"pr_list",
 		"List pull requests in a repository",
@@ -19,7 +21,8 @@ func registerPRTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"state": {
 					"type": "string",
@@ -28,12 +31,22 @@ func registerPRTools(r *server.ToolRegistry) {
 				},
 				"limit": {
 					"type": "integer",
-					"description": "Maximum number of pull requests to list (default 30)"
+					"description": "Maximum number of pull requests to list (default 30)",
+					"minimum": 1
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh pr list --json supports"
+				},
+				"jq": {
+					"type": "string",
+					"description": "jq expression gh applies to the JSON output before returning it, e.g. '.[] | {number, title}'"
 				}
-			},
-			"required": ["repo"]
+			}
 		}`),
 		handlePRList,
+		structured[[]PullRequest](),
 	)
 
 	r.Register(
@@ -44,34 +57,387 @@ func registerPRTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"number": {
 					"type": "integer",
-					"description": "Pull request number"
+					"description": "Pull request number",
+					"minimum": 1
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh pr view --json supports"
 				}
 			},
-			"required": ["repo", "number"]
+			"required": ["number"]
 		}`),
 		handlePRView,
+		structured[PullRequest](),
+	)
+
+	r.Register(
+		"pr_create",
+		"Open a pull request. Without a body, the body comes from the repository's pull request template, looked up where GitHub looks: .github/, the root or docs/, or a PULL_REQUEST_TEMPLATE/ directory",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"base": {
+					"type": "string",
+					"description": "Branch to merge into; defaults to the repository's default branch",
+					"format": "git-ref"
+				},
+				"head": {
+					"type": "string",
+					"description": "Branch with the changes, or OWNER:branch for a branch in a fork",
+					"format": "head-ref"
+				},
+				"title": {
+					"type": "string",
+					"description": "Pull request title"
+				},
+				"body": {
+					"type": "string",
+					"description": "Pull request body; defaults to the repository's pull request template"
+				},
+				"template": {
+					"type": "string",
+					"description": "File name of the template in the repository's PULL_REQUEST_TEMPLATE/ directory to use as the body, for repositories with several"
+				},
+				"draft": {
+					"type": "boolean",
+					"description": "Open the pull request as a draft"
+				},
+				"reviewers": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Users to request reviews from"
+				},
+				"team_reviewers": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Teams to request reviews from, as ORG/TEAM or a team slug in the repository's organization"
+				},
+				"assignees": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Users to assign"
+				},
+				"labels": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Labels to add"
+				},
+				"milestone": {
+					"type": "string",
+					"description": "Milestone title"
+				}
+			},
+			"required": ["head", "title"]
+		}`),
+		handlePRCreate,
+		mutating,
+		structured[CreatedPullRequest](),
+	)
+
+	r.Register(
+		"pr_diff",
+		"Show the changes in a pull request as a unified diff or per-file patches, a page of files at a time",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"number": {
+					"type": "integer",
+					"description": "Pull request number",
+					"minimum": 1
+				},
+				"format": {
+					"type": "string",
+					"description": "unified (one diff text, default) or patches (JSON entries with each file's status, counts and patch)",
+					"enum": ["unified", "patches"]
+				},
+				"paths": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Only files matching one of these globs, e.g. 'internal/**/*.go'; * stays within a directory, ** crosses directories"
+				},
+				"status": {
+					"type": "array",
+					"items": {
+						"type": "string",
+						"enum": ["added", "removed", "modified", "renamed", "copied", "changed", "unchanged"]
+					},
+					"description": "Only files with one of these statuses"
+				},
+				"start_line": {
+					"type": "integer",
+					"description": "Only hunks touching this line of the new file or later (1-based); files without such hunks are skipped",
+					"minimum": 1
+				},
+				"end_line": {
+					"type": "integer",
+					"description": "Only hunks touching this line of the new file or earlier (1-based, inclusive)",
+					"minimum": 1
+				},
+				"offset": {
+					"type": "integer",
+					"description": "Number of matching files to skip for pagination",
+					"minimum": 0
+				},
+				"limit": {
+					"type": "integer",
+					"description": "Maximum number of files to return (default 20)",
+					"minimum": 1
+				}
+			},
+			"required": ["number"]
+		}`),
+		handlePRDiff,
+	)
+
+	r.Register(
+		"pr_files",
+		"List every file a pull request changes with line counts, rename sources and whether it is generated, vendored or a lockfile. Rename sources come from the REST API, which lists only the first 3000 files; on larger pull requests later renames have no previous_path and previous_paths_truncated is set",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"number": {
+					"type": "integer",
+					"description": "Pull request number",
+					"minimum": 1
+				},
+				"exclude_generated": {
+					"type": "boolean",
+					"description": "Leave out generated, vendored and lockfiles; they still count toward the totals"
+				}
+			},
+			"required": ["number"]
+		}`),
+		handlePRFiles,
+		structured[PullRequestFiles](),
+	)
+
+	r.Register(
+		"pr_review",
+		"Approve, request changes on or comment on a pull request, with inline comments on single lines or line ranges and suggested changes",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"number": {
+					"type": "integer",
+					"description": "Pull request number",
+					"minimum": 1
+				},
+				"event": {
+					"type": "string",
+					"description": "APPROVE, REQUEST_CHANGES (needs a body) or COMMENT (needs a body or comments)",
+					"enum": ["APPROVE", "REQUEST_CHANGES", "COMMENT"]
+				},
+				"body": {
+					"type": "string",
+					"description": "Review summary"
+				},
+				"commit_id": {
+					"type": "string",
+					"description": "Commit SHA the comments' lines refer to; defaults to the pull request's head, so pass the SHA the diff was read at if the branch may have moved",
+					"format": "git-ref"
+				},
+				"comments": {
+					"type": "array",
+					"description": "Inline comments, each anchored to a line or line range of the diff",
+					"items": {
+						"type": "object",
+						"properties": {
+							"path": {
+								"type": "string",
+								"description": "File path relative to the repository root",
+								"format": "repo-path"
+							},
+							"line": {
+								"type": "integer",
+								"description": "Line the comment is on, or the last line of a range, numbered as in the file on that side",
+								"minimum": 1
+							},
+							"start_line": {
+								"type": "integer",
+								"description": "First line of a multi-line range; must be less than line",
+								"minimum": 1
+							},
+							"side": {
+								"type": "string",
+								"description": "RIGHT (default) for the new file: added and unchanged lines; LEFT for the old file: removed lines",
+								"enum": ["LEFT", "RIGHT"]
+							},
+							"start_side": {
+								"type": "string",
+								"description": "Side of start_line; defaults to side",
+								"enum": ["LEFT", "RIGHT"]
+							},
+							"body": {
+								"type": "string",
+								"description": "Comment text"
+							},
+							"suggestion": {
+								"type": "string",
+								"description": "Replacement for the commented lines, added to the body as a suggested change the author can apply; an empty string suggests deleting them. RIGHT side only"
+							}
+						},
+						"required": ["path", "line"]
+					}
+				}
+			},
+			"required": ["number", "event"]
+		}`),
+		handlePRReview,
+		mutating,
+		structured[SubmittedReview](),
+	)
+
+	r.Register(
+		"pr_review_threads",
+		"List a pull request's inline review threads with their file, line, diff hunk, resolved and outdated state and every reply",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"number": {
+					"type": "integer",
+					"description": "Pull request number",
+					"minimum": 1
+				},
+				"state": {
+					"type": "string",
+					"description": "Filter by resolution: unresolved, resolved, all (default all)",
+					"enum": ["unresolved", "resolved", "all"]
+				}
+			},
+			"required": ["number"]
+		}`),
+		handlePRReviewThreads,
+		structured[[]ReviewThread](),
+	)
+
+	r.Register(
+		"pr_review_thread_reply",
+		"Reply to a review thread",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"thread_id": {
+					"type": "string",
+					"description": "Review thread ID, as pr_review_threads returns it"
+				},
+				"body": {
+					"type": "string",
+					"description": "Reply text"
+				}
+			},
+			"required": ["thread_id", "body"]
+		}`),
+		handlePRReviewThreadReply,
+		mutating,
+		structured[ReviewComment](),
+	)
+
+	r.Register(
+		"pr_review_thread_resolve",
+		"Mark a review thread as resolved",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"thread_id": {
+					"type": "string",
+					"description": "Review thread ID, as pr_review_threads returns it"
+				}
+			},
+			"required": ["thread_id"]
+		}`),
+		handleReviewThreadResolution("resolveReviewThread", resolveReviewThreadMutation),
+		idempotent,
+		structured[ReviewThreadState](),
+	)
+
+	r.Register(
+		"pr_review_thread_unresolve",
+		"Mark a resolved review thread as unresolved again",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"repo": {
+					"type": "string",
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"thread_id": {
+					"type": "string",
+					"description": "Review thread ID, as pr_review_threads returns it"
+				}
+			},
+			"required": ["thread_id"]
+		}`),
+		handleReviewThreadResolution("unresolveReviewThread", unresolveReviewThreadMutation),
+		idempotent,
+		structured[ReviewThreadState](),
 	)
 }
 
 func handlePRList(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
-		Repo  string `json:"repo"`
-		State string `json:"state"`
-		Limit int    `json:"limit"`
+		Repo   string   `json:"repo"`
+		State  string   `json:"state"`
+		Limit  int      `json:"limit"`
+		Fields []string `json:"fields"`
+		JQ     string   `json:"jq"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	fields, err := selectFields(params.Fields, prFields, "number,title,state,author,baseRefName,headRefName,createdAt,updatedAt,url")
+	if err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
 	ghArgs := []string{
 		"pr", "list",
 		"-R", params.Repo,
-		"--json", "number,title,state,author,baseRefName,headRefName,createdAt,updatedAt,url",
+		"--json", fields,
 	}
 
 	if params.State != "" {
@@ -82,9 +448,13 @@ func handlePRList(ctx context.Context, args json.RawMessage) (*protocol.ToolCall
 		ghArgs = append(ghArgs, "--limit", fmt.Sprintf("%d", params.Limit))
 	}
 
+	if params.JQ != "" {
+		ghArgs = append(ghArgs, "--jq", params.JQ)
+	}
+
 	out, err := gh.Run(ctx, ghArgs...)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh pr list: %v", err)), nil
+		return ghErrorResult("gh pr list", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -96,21 +466,27 @@ func handlePRList(ctx context.Context, args json.RawMessage) (*protocol.ToolCall
 
 func handlePRView(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
-		Repo   string `json:"repo"`
-		Number int    `json:"number"`
+		Repo   string   `json:"repo"`
+		Number int      `json:"number"`
+		Fields []string `json:"fields"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	fields, err := selectFields(params.Fields, prFields, "number,title,state,body,author,baseRefName,headRefName,labels,reviewDecision,commits,comments,createdAt,updatedAt,url")
+	if err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
 	out, err := gh.Run(ctx,
 		"pr", "view", fmt.Sprintf("%d", params.Number),
 		"-R", params.Repo,
-		"--json", "number,title,state,body,author,baseRefName,headRefName,labels,reviewDecision,commits,comments,createdAt,updatedAt,url",
+		"--json", fields,
 	)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh pr view: %v", err)), nil
+		return ghErrorResult("gh pr view", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -119,3 +495,116 @@ func handlePRView(ctx context.Context, args json.RawMessage) (*protocol.ToolCall
 		},
 	}, nil
 }
+
+func handlePRCreate(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+	var params struct {
+		Repo          string   `json:"repo"`
+		Base          string   `json:"base"`
+		Head          string   `json:"head"`
+		Title         string   `json:"title"`
+		Body          string   `json:"body"`
+		Template      string   `json:"template"`
+		Draft         bool     `json:"draft"`
+		Reviewers     []string `json:"reviewers"`
+		TeamReviewers []string `json:"team_reviewers"`
+		Assignees     []string `json:"assignees"`
+		Labels        []string `json:"labels"`
+		Milestone     string   `json:"milestone"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	if params.Body != "" && params.Template != "" {
+		return errorResult(gh.KindValidation, "pass body or template, not both"), nil
+	}
+
+	body := params.Body
+	if body == "" {
+		templates, err := listPRTemplates(ctx, params.Repo, params.Base)
+		if err != nil {
+			return ghErrorResult("gh api graphql pull request templates", err), nil
+		}
+
+		templatePath, err := templates.pick(params.Template)
+		if err != nil {
+			return errorResult(gh.KindValidation, "%v", err), nil
+		}
+
+		if templatePath != "" {
+			if body, err = readRepoFile(ctx, params.Repo, templatePath, params.Base); err != nil {
+				return ghErrorResult("gh api contents", err), nil
+			}
+		}
+	}
+
+	ghArgs := []string{
+		"pr", "create",
+		"-R", params.Repo,
+		"--head", params.Head,
+		"--title", params.Title,
+		"--body", body,
+	}
+
+	if params.Base != "" {
+		ghArgs = append(ghArgs, "--base", params.Base)
+	}
+
+	if params.Draft {
+		ghArgs = append(ghArgs, "--draft")
+	}
+
+	owner, _, _ := strings.Cut(params.Repo, "/")
+
+	for _, reviewer := range params.Reviewers {
+		ghArgs = append(ghArgs, "--reviewer", reviewer)
+	}
+
+	// gh tells teams from users by the slash in ORG/TEAM.
+	for _, team := range params.TeamReviewers {
+		if !strings.Contains(team, "/") {
+			team = owner + "/" + team
+		}
+
+		ghArgs = append(ghArgs, "--reviewer", team)
+	}
+
+	for _, assignee := range params.Assignees {
+		ghArgs = append(ghArgs, "--assignee", assignee)
+	}
+
+	for _, label := range params.Labels {
+		ghArgs = append(ghArgs, "--label", label)
+	}
+
+	if params.Milestone != "" {
+		ghArgs = append(ghArgs, "--milestone", params.Milestone)
+	}
+
+	out, err := gh.Run(ctx, ghArgs...)
+	if err != nil {
+		return ghErrorResult("gh pr create", err), nil
+	}
+
+	// gh prints the new pull request's URL as its last line of output.
+	out = strings.TrimSpace(out)
+
+	created := CreatedPullRequest{URL: out[strings.LastIndexByte(out, '\n')+1:]}
+
+	created.Number, err = strconv.Atoi(path.Base(created.URL))
+	if err != nil {
+		return errorResult(kindInternal, "parsing pull request URL %q: %v", created.URL, err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(created, "", "  ")
+	if err != nil {
+		return errorResult(kindInternal, "marshaling pull request: %v", err), nil
+	}
+
+	return &protocol.ToolCallResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent(string(resultJSON)),
+		},
+	}, nil
+}
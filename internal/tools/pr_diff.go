@@ -0,0 +1,335 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+const defaultDiffFiles = 20
+
+// prFile is an entry of the pull request files API. Patch is empty for
+// binary files and for diffs too large for the API to include.
+type prFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename,omitempty"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Patch            string `json:"patch,omitempty"`
+}
+
+// prFilesVersion names the state of a pull request's file list, which
+// changes only with a push to the head branch or a new base branch.
+func prFilesVersion(baseRef, headSHA string) string {
+	return baseRef + ".." + headSHA
+}
+
+// pullRequestVersion looks up the prFilesVersion of a pull request.
+func pullRequestVersion(ctx context.Context, repo string, number int) (string, error) {
+	resp, err := gh.Default().REST(ctx, gh.Request{
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("repos/%s/pulls/%d", repo, number),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var pr struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	}
+
+	if err := json.Unmarshal(resp.Body, &pr); err != nil {
+		return "", fmt.Errorf("parsing pull request: %w", err)
+	}
+
+	return prFilesVersion(pr.Base.Ref, pr.Head.SHA), nil
+}
+
+// listPRFiles returns every file a pull request changes, as far as the
+// REST API lists them (at most 3000). The pages are cached under version,
+// the pull request's prFilesVersion, so paging through a diff fetches
+// them once per push.
+func listPRFiles(ctx context.Context, repo string, number int, version string) ([]prFile, error) {
+	req := gh.Request{
+		Method:  http.MethodGet,
+		Path:    fmt.Sprintf("repos/%s/pulls/%d/files", repo, number),
+		Params:  url.Values{"per_page": {"100"}},
+		Version: version,
+	}
+
+	var files []prFile
+
+	err := gh.Paginate(ctx, gh.Default(), req, func(resp *gh.Response) error {
+		var page []prFile
+		if err := json.Unmarshal(resp.Body, &page); err != nil {
+			return fmt.Errorf("parsing pull request files: %w", err)
+		}
+
+		files = append(files, page...)
+
+		return nil
+	})
+
+	return files, err
+}
+
+func handlePRDiff(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+	var params struct {
+		Repo      string   `json:"repo"`
+		Number    int      `json:"number"`
+		Format    string   `json:"format"`
+		Paths     []string `json:"paths"`
+		Status    []string `json:"status"`
+		StartLine int      `json:"start_line"`
+		EndLine   int      `json:"end_line"`
+		Offset    int      `json:"offset"`
+		Limit     int      `json:"limit"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	if params.StartLine > 0 && params.EndLine > 0 && params.StartLine > params.EndLine {
+		return errorResult(gh.KindValidation, "start_line %d is after end_line %d", params.StartLine, params.EndLine), nil
+	}
+
+	globs := make([]*regexp.Regexp, len(params.Paths))
+	for i, pattern := range params.Paths {
+		globs[i] = globRegexp(pattern)
+	}
+
+	version, err := pullRequestVersion(ctx, params.Repo, params.Number)
+	if err != nil {
+		return ghErrorResult("gh api pulls", err), nil
+	}
+
+	files, err := listPRFiles(ctx, params.Repo, params.Number, version)
+	if err != nil {
+		return ghErrorResult("gh api pulls/files", err), nil
+	}
+
+	var matching []prFile
+	for _, f := range files {
+		if len(params.Status) > 0 && !slices.Contains(params.Status, f.Status) {
+			continue
+		}
+
+		if len(globs) > 0 && !slices.ContainsFunc(globs, func(g *regexp.Regexp) bool { return g.MatchString(f.Filename) }) {
+			continue
+		}
+
+		// Binary and oversized files have no patch, so no lines in a window.
+		if params.StartLine > 0 || params.EndLine > 0 {
+			if f.Patch = hunksInRange(f.Patch, params.StartLine, params.EndLine); f.Patch == "" {
+				continue
+			}
+		}
+
+		matching = append(matching, f)
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultDiffFiles
+	}
+
+	if params.Offset > 0 && params.Offset >= len(matching) {
+		return errorResult(gh.KindValidation, "offset %d is past the end: %d files match", params.Offset, len(matching)), nil
+	}
+
+	start := params.Offset
+	end := min(start+limit, len(matching))
+	page := matching[start:end]
+
+	if params.Format == "patches" {
+		result := struct {
+			Entries []prFile `json:"entries"`
+			Total   int      `json:"total"`
+			Offset  int      `json:"offset"`
+			Count   int      `json:"count"`
+		}{
+			Entries: page,
+			Total:   len(matching),
+			Offset:  start,
+			Count:   len(page),
+		}
+
+		if result.Entries == nil {
+			result.Entries = []prFile{}
+		}
+
+		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errorResult(kindInternal, "marshaling patches: %v", err), nil
+		}
+
+		return &protocol.ToolCallResult{
+			Content: []protocol.ContentBlock{
+				protocol.TextContent(string(resultJSON)),
+			},
+		}, nil
+	}
+
+	if len(matching) == 0 {
+		return &protocol.ToolCallResult{
+			Content: []protocol.ContentBlock{
+				protocol.TextContent(fmt.Sprintf("No files in pull request #%d match the filters (%d files changed)", params.Number, len(files))),
+			},
+		}, nil
+	}
+
+	var sb strings.Builder
+	for _, f := range page {
+		writeUnifiedDiff(&sb, f)
+	}
+
+	if end < len(matching) {
+		fmt.Fprintf(&sb, "\n[files %d-%d of %d; call pr_diff again with \"offset\": %d for more]\n", start+1, end, len(matching), end)
+	}
+
+	return &protocol.ToolCallResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent(sb.String()),
+		},
+	}, nil
+}
+
+// writeUnifiedDiff writes a file's patch with the git diff headers the
+// files API leaves out.
+func writeUnifiedDiff(sb *strings.Builder, f prFile) {
+	oldName := f.Filename
+	if f.PreviousFilename != "" {
+		oldName = f.PreviousFilename
+	}
+
+	fmt.Fprintf(sb, "diff --git a/%s b/%s\n", oldName, f.Filename)
+
+	if oldName != f.Filename {
+		fmt.Fprintf(sb, "rename from %s\nrename to %s\n", oldName, f.Filename)
+	}
+
+	if f.Patch == "" {
+		sb.WriteString("(no patch: binary file or diff too large)\n")
+		return
+	}
+
+	from, to := "a/"+oldName, "b/"+f.Filename
+
+	switch f.Status {
+	case "added":
+		from = "/dev/null"
+	case "removed":
+		to = "/dev/null"
+	}
+
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", from, to)
+	sb.WriteString(f.Patch)
+
+	if !strings.HasSuffix(f.Patch, "\n") {
+		sb.WriteString("\n")
+	}
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// hunksInRange keeps the hunks of patch whose new-file lines overlap
+// start..end; zero leaves that side open.
+func hunksInRange(patch string, start, end int) string {
+	var kept, hunk strings.Builder
+
+	keep := false
+
+	flush := func() {
+		if keep {
+			kept.WriteString(hunk.String())
+		}
+
+		hunk.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			flush()
+
+			first, _ := strconv.Atoi(m[1])
+
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+
+			last := first + max(count, 1) - 1
+			keep = (start == 0 || last >= start) && (end == 0 || first <= end)
+		}
+
+		hunk.WriteString(line)
+	}
+
+	flush()
+
+	return kept.String()
+}
+
+// globRegexp compiles a path glob: * and ? match within a directory,
+// ** across directories, and [...] a character class.
+func globRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				sb.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			if j := strings.IndexByte(pattern[i+1:], ']'); j >= 0 {
+				class := pattern[i+1 : i+1+j]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+
+				sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+				i += j + 1
+			} else {
+				sb.WriteString(`\[`)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+
+	return re
+}
@@ -0,0 +1,41 @@
+package tools
+
+import "testing"
+
+func TestGlobRegexp(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/hello/main.go", true},
+		{"internal/**", "internal/tools/pr.go", true},
+		{"docs/?.md", "docs/a.md", true},
+		{"docs/[!a].md", "docs/a.md", false},
+		{"docs/[!a].md", "docs/b.md", true},
+		{"a+b.txt", "a+b.txt", true},
+		{"ünï/*.txt", "ünï/x.txt", true},
+	} {
+		if got := globRegexp(tc.pattern).MatchString(tc.name); got != tc.match {
+			t.Errorf("%q matching %q = %v, want %v", tc.pattern, tc.name, got, tc.match)
+		}
+	}
+}
+
+func TestHunksInRange(t *testing.T) {
+	patch := "@@ -1,2 +1,2 @@\n-a\n+b\n@@ -10,3 +10,4 @@ func f() {\n x\n+y\n@@ -50 +51 @@\n-z\n+w\n"
+
+	if got, want := hunksInRange(patch, 11, 0), "@@ -10,3 +10,4 @@ func f() {\n x\n+y\n@@ -50 +51 @@\n-z\n+w\n"; got != want {
+		t.Errorf("from line 11: got %q, want %q", got, want)
+	}
+
+	if got, want := hunksInRange(patch, 0, 2), "@@ -1,2 +1,2 @@\n-a\n+b\n"; got != want {
+		t.Errorf("to line 2: got %q, want %q", got, want)
+	}
+
+	if got := hunksInRange(patch, 20, 40); got != "" {
+		t.Errorf("between hunks: got %q", got)
+	}
+}
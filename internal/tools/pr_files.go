@@ -0,0 +1,291 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// The GraphQL files connection pages past the 3000 files the REST API
+// stops at, but has no rename sources; those come from REST when needed.
+const prFilesQuery = `query($owner: String!, $name: String!, $number: Int!, $endCursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      baseRefName
+      headRefOid
+      files(first: 100, after: $endCursor) {
+        totalCount
+        pageInfo { hasNextPage endCursor }
+        nodes { path additions deletions changeType }
+      }
+    }
+  }
+}`
+
+// Paths treated as generated without a .gitattributes entry.
+var (
+	lockfiles = []string{
+		"go.sum", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+		"bun.lockb", "Cargo.lock", "Gemfile.lock", "composer.lock", "poetry.lock",
+		"Pipfile.lock", "uv.lock", "flake.lock", "mix.lock", "pubspec.lock", "Podfile.lock",
+		"gradle.lockfile", "packages.lock.json",
+	}
+
+	vendoredPatterns = []*regexp.Regexp{
+		globRegexp("**/vendor/**"),
+		globRegexp("**/node_modules/**"),
+		globRegexp("**/third_party/**"),
+	}
+
+	generatedPatterns = []*regexp.Regexp{
+		globRegexp("**/*.pb.go"),
+		globRegexp("**/*.pb.gw.go"),
+		globRegexp("**/*_pb2.py"),
+		globRegexp("**/*_pb2_grpc.py"),
+		globRegexp("**/*.pb.cc"),
+		globRegexp("**/*.pb.h"),
+		globRegexp("**/*_pb.js"),
+		globRegexp("**/*_pb.d.ts"),
+		globRegexp("**/*_generated.go"),
+		globRegexp("**/zz_generated*.go"),
+		globRegexp("**/*.min.js"),
+		globRegexp("**/*.min.css"),
+	}
+)
+
+func handlePRFiles(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+	var params struct {
+		Repo             string `json:"repo"`
+		Number           int    `json:"number"`
+		ExcludeGenerated bool   `json:"exclude_generated"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	owner, name, _ := strings.Cut(params.Repo, "/")
+
+	var (
+		files     []PullRequestFile
+		headOid   string
+		baseRef   string
+		cursor    string
+		renamed   bool
+		truncated bool
+	)
+
+	for {
+		variables := map[string]any{"owner": owner, "name": name, "number": params.Number}
+		if cursor != "" {
+			variables["endCursor"] = cursor
+		}
+
+		out, err := gh.Default().GraphQL(ctx, prFilesQuery, variables)
+		if err != nil {
+			return ghErrorResult("gh api graphql pull request files", err), nil
+		}
+
+		var resp struct {
+			Data struct {
+				Repository struct {
+					PullRequest *struct {
+						BaseRefName string `json:"baseRefName"`
+						HeadRefOid  string `json:"headRefOid"`
+						Files       struct {
+							PageInfo struct {
+								HasNextPage bool   `json:"hasNextPage"`
+								EndCursor   string `json:"endCursor"`
+							} `json:"pageInfo"`
+							Nodes []struct {
+								Path       string `json:"path"`
+								Additions  int    `json:"additions"`
+								Deletions  int    `json:"deletions"`
+								ChangeType string `json:"changeType"`
+							} `json:"nodes"`
+						} `json:"files"`
+					} `json:"pullRequest"`
+				} `json:"repository"`
+			} `json:"data"`
+		}
+
+		if err := json.Unmarshal(out.Body, &resp); err != nil {
+			return errorResult(kindInternal, "parsing pull request files: %v", err), nil
+		}
+
+		pr := resp.Data.Repository.PullRequest
+		if pr == nil {
+			return errorResult(gh.KindNotFound, "pull request %s#%d not found", params.Repo, params.Number), nil
+		}
+
+		headOid = pr.HeadRefOid
+		baseRef = pr.BaseRefName
+
+		for _, node := range pr.Files.Nodes {
+			changeType := strings.ToLower(node.ChangeType)
+			renamed = renamed || changeType == "renamed"
+
+			files = append(files, PullRequestFile{
+				Path:       node.Path,
+				ChangeType: changeType,
+				Additions:  node.Additions,
+				Deletions:  node.Deletions,
+			})
+		}
+
+		if !pr.Files.PageInfo.HasNextPage {
+			break
+		}
+
+		cursor = pr.Files.PageInfo.EndCursor
+	}
+
+	if renamed {
+		restFiles, err := listPRFiles(ctx, params.Repo, params.Number, prFilesVersion(baseRef, headOid))
+		if err != nil {
+			return ghErrorResult("gh api pulls/files", err), nil
+		}
+
+		previous := make(map[string]string)
+		for _, f := range restFiles {
+			if f.PreviousFilename != "" {
+				previous[f.Filename] = f.PreviousFilename
+			}
+		}
+
+		for i := range files {
+			files[i].PreviousPath = previous[files[i].Path]
+		}
+
+		// The REST list ends at 3000 files; renames past it keep no source.
+		truncated = len(restFiles) < len(files)
+	}
+
+	attributes, err := readRepoFile(ctx, params.Repo, ".gitattributes", headOid)
+	if err != nil {
+		return ghErrorResult("gh api contents", err), nil
+	}
+
+	rules := parseLinguistAttributes(attributes)
+
+	result := PullRequestFiles{Entries: []PullRequestFile{}, Total: len(files), PreviousPathsTruncated: truncated}
+
+	for _, f := range files {
+		f.GeneratedReason = generatedReason(f.Path, rules)
+		f.Generated = f.GeneratedReason != ""
+
+		result.Additions += f.Additions
+		result.Deletions += f.Deletions
+
+		if f.Generated {
+			result.Generated++
+
+			if params.ExcludeGenerated {
+				continue
+			}
+		}
+
+		result.Entries = append(result.Entries, f)
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(kindInternal, "marshaling pull request files: %v", err), nil
+	}
+
+	return &protocol.ToolCallResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent(string(resultJSON)),
+		},
+	}, nil
+}
+
+// linguistRule is a .gitattributes line that sets or unsets
+// linguist-generated or linguist-vendored.
+type linguistRule struct {
+	pattern *regexp.Regexp
+	attr    string
+	set     bool
+}
+
+// parseLinguistAttributes reads the linguist-generated and
+// linguist-vendored attributes of a root .gitattributes file. Patterns
+// without a slash match a file name at any depth, as in git.
+func parseLinguistAttributes(text string) []linguistRule {
+	var rules []linguistRule
+
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		pattern := fields[0]
+		if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+			pattern = strings.TrimPrefix(pattern, "/")
+		} else {
+			pattern = "**/" + pattern
+		}
+
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+
+		re := globRegexp(pattern)
+
+		for _, attr := range fields[1:] {
+			name, value, hasValue := strings.Cut(strings.TrimPrefix(attr, "-"), "=")
+			if name != "linguist-generated" && name != "linguist-vendored" {
+				continue
+			}
+
+			set := !strings.HasPrefix(attr, "-") && (!hasValue || value == "true")
+			rules = append(rules, linguistRule{pattern: re, attr: name, set: set})
+		}
+	}
+
+	return rules
+}
+
+// generatedReason says why a path counts as generated, or returns the
+// empty string. .gitattributes decides where it has a matching rule, the
+// last one winning as in git; otherwise the built-in patterns apply.
+func generatedReason(filePath string, rules []linguistRule) string {
+	decided := map[string]bool{}
+
+	for _, rule := range rules {
+		if rule.pattern.MatchString(filePath) {
+			decided[rule.attr] = rule.set
+		}
+	}
+
+	for _, attr := range []string{"linguist-generated", "linguist-vendored"} {
+		if set, ok := decided[attr]; ok && set {
+			return attr
+		}
+	}
+
+	matches := func(patterns []*regexp.Regexp) bool {
+		return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool { return re.MatchString(filePath) })
+	}
+
+	_, generatedKnown := decided["linguist-generated"]
+	_, vendoredKnown := decided["linguist-vendored"]
+
+	switch {
+	case !generatedKnown && slices.Contains(lockfiles, path.Base(filePath)):
+		return "lockfile"
+	case !generatedKnown && matches(generatedPatterns):
+		return "generated"
+	case !vendoredKnown && matches(vendoredPatterns):
+		return "vendored"
+	}
+
+	return ""
+}
@@ -0,0 +1,28 @@
+package tools
+
+import "testing"
+
+func TestGeneratedReason(t *testing.T) {
+	rules := parseLinguistAttributes(`# comment
+docs/api/ linguist-generated
+*.lock -linguist-generated
+/assets/*.svg linguist-vendored=true
+assets/logo.svg linguist-vendored=false
+`)
+
+	for path, want := range map[string]string{
+		"docs/api/index.html":      "linguist-generated",
+		"sub/docs/api/index.html":  "",
+		"yarn.lock":                "",
+		"web/package-lock.json":    "lockfile",
+		"assets/icon.svg":          "linguist-vendored",
+		"assets/logo.svg":          "",
+		"proto/greet.pb.go":        "generated",
+		"vendor/golang.org/x/a.go": "vendored",
+		"main.go":                  "",
+	} {
+		if got := generatedReason(path, rules); got != want {
+			t.Errorf("generatedReason(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
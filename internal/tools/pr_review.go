@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// reviewComment is an inline comment as pr_review takes it. Suggestion is
+// a pointer since an empty suggestion, deleting the lines, differs from
+// none.
+type reviewComment struct {
+	Path       string  `json:"path"`
+	Line       int     `json:"line"`
+	StartLine  int     `json:"start_line"`
+	Side       string  `json:"side"`
+	StartSide  string  `json:"start_side"`
+	Body       string  `json:"body"`
+	Suggestion *string `json:"suggestion"`
+}
+
+// draftReviewComment is a comment of the create review API's request
+// body, anchored by line numbers rather than the deprecated diff position.
+type draftReviewComment struct {
+	Path      string `json:"path"`
+	Body      string `json:"body"`
+	Line      int    `json:"line"`
+	Side      string `json:"side"`
+	StartLine int    `json:"start_line,omitempty"`
+	StartSide string `json:"start_side,omitempty"`
+}
+
+func handlePRReview(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+	var params struct {
+		Repo     string          `json:"repo"`
+		Number   int             `json:"number"`
+		Event    string          `json:"event"`
+		Body     string          `json:"body"`
+		CommitID string          `json:"commit_id"`
+		Comments []reviewComment `json:"comments"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	var problems []string
+
+	switch {
+	case params.Event == "REQUEST_CHANGES" && strings.TrimSpace(params.Body) == "":
+		problems = append(problems, "REQUEST_CHANGES needs a body")
+	case params.Event == "COMMENT" && strings.TrimSpace(params.Body) == "" && len(params.Comments) == 0:
+		problems = append(problems, "COMMENT needs a body or comments")
+	}
+
+	comments := make([]draftReviewComment, 0, len(params.Comments))
+
+	for i, c := range params.Comments {
+		comment, err := c.draft()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("comments[%d]: %v", i, err))
+			continue
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if len(problems) > 0 {
+		return errorResult(gh.KindValidation, "invalid arguments: %s", strings.Join(problems, "; ")), nil
+	}
+
+	body := map[string]any{
+		"event":    params.Event,
+		"body":     params.Body,
+		"comments": comments,
+	}
+
+	if params.CommitID != "" {
+		body["commit_id"] = params.CommitID
+	}
+
+	resp, err := gh.Default().REST(ctx, gh.Request{
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("repos/%s/pulls/%d/reviews", params.Repo, params.Number),
+		Body:   body,
+	})
+	if err != nil {
+		return ghErrorResult("gh api pulls/reviews", err), nil
+	}
+
+	var review struct {
+		ID          int64  `json:"id"`
+		State       string `json:"state"`
+		HTMLURL     string `json:"html_url"`
+		SubmittedAt string `json:"submitted_at"`
+	}
+
+	if err := json.Unmarshal(resp.Body, &review); err != nil {
+		return errorResult(kindInternal, "parsing review: %v", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(SubmittedReview{
+		ID:          review.ID,
+		State:       review.State,
+		URL:         review.HTMLURL,
+		SubmittedAt: review.SubmittedAt,
+		Comments:    len(comments),
+	}, "", "  ")
+	if err != nil {
+		return errorResult(kindInternal, "marshaling review: %v", err), nil
+	}
+
+	return &protocol.ToolCallResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent(string(resultJSON)),
+		},
+	}, nil
+}
+
+// draft checks c's anchor and builds the comment GitHub expects, with any
+// suggestion appended to the body.
+func (c reviewComment) draft() (draftReviewComment, error) {
+	side := c.Side
+	if side == "" {
+		side = "RIGHT"
+	}
+
+	comment := draftReviewComment{
+		Path: c.Path,
+		Body: c.Body,
+		Line: c.Line,
+		Side: side,
+	}
+
+	if c.StartLine != 0 {
+		if c.StartLine >= c.Line {
+			return comment, fmt.Errorf("start_line %d must be less than line %d", c.StartLine, c.Line)
+		}
+
+		comment.StartLine = c.StartLine
+		comment.StartSide = c.StartSide
+
+		if comment.StartSide == "" {
+			comment.StartSide = side
+		}
+	} else if c.StartSide != "" {
+		return comment, fmt.Errorf("start_side needs start_line")
+	}
+
+	if c.Suggestion == nil {
+		if strings.TrimSpace(c.Body) == "" {
+			return comment, fmt.Errorf("needs a body or a suggestion")
+		}
+
+		return comment, nil
+	}
+
+	if side != "RIGHT" || (comment.StartSide != "" && comment.StartSide != "RIGHT") {
+		return comment, fmt.Errorf("suggestions can only replace RIGHT side lines")
+	}
+
+	if comment.Body != "" {
+		comment.Body += "\n\n"
+	}
+
+	comment.Body += suggestionBlock(*c.Suggestion)
+
+	return comment, nil
+}
+
+// suggestionBlock formats text as a suggested change. The fence is made
+// longer than any backtick run in text, so suggestions of Markdown with
+// code blocks survive.
+func suggestionBlock(text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(fence + "suggestion\n")
+	sb.WriteString(text)
+
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fence)
+
+	return sb.String()
+}
@@ -0,0 +1,51 @@
+package tools
+
+import "testing"
+
+func TestSuggestionBlock(t *testing.T) {
+	for _, tc := range []struct {
+		text, want string
+	}{
+		{"return nil", "```suggestion\nreturn nil\n```"},
+		{"a\nb\n", "```suggestion\na\nb\n```"},
+		{"", "```suggestion\n```"},
+		{"```go\nx\n```", "````suggestion\n```go\nx\n```\n````"},
+	} {
+		if got := suggestionBlock(tc.text); got != tc.want {
+			t.Errorf("suggestionBlock(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestReviewCommentDraft(t *testing.T) {
+	suggestion := "x := 1"
+
+	comment, err := reviewComment{Path: "a.go", Line: 12, StartLine: 10, Body: "Simpler:", Suggestion: &suggestion}.draft()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := draftReviewComment{
+		Path:      "a.go",
+		Body:      "Simpler:\n\n```suggestion\nx := 1\n```",
+		Line:      12,
+		Side:      "RIGHT",
+		StartLine: 10,
+		StartSide: "RIGHT",
+	}
+
+	if comment != want {
+		t.Errorf("got %+v, want %+v", comment, want)
+	}
+
+	for name, c := range map[string]reviewComment{
+		"reversed range":  {Path: "a.go", Line: 3, StartLine: 5, Body: "x"},
+		"no body":         {Path: "a.go", Line: 3},
+		"left suggestion": {Path: "a.go", Line: 3, Side: "LEFT", Suggestion: &suggestion},
+		"start_side only": {Path: "a.go", Line: 3, StartSide: "LEFT", Body: "x"},
+	} {
+		if _, err := c.draft(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+const reviewCommentFields = `id author { login } body createdAt url diffHunk`
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $endCursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 50, after: $endCursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id path line startLine originalLine diffSide isResolved isOutdated
+          resolvedBy { login }
+          comments(first: 100) {
+            pageInfo { hasNextPage endCursor }
+            nodes { ` + reviewCommentFields + ` }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// Threads with more replies than the first page holds are finished one
+// thread at a time.
+const reviewThreadCommentsQuery = `query($id: ID!, $endCursor: String) {
+  node(id: $id) {
+    ... on PullRequestReviewThread {
+      comments(first: 100, after: $endCursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes { ` + reviewCommentFields + ` }
+      }
+    }
+  }
+}`
+
+const reviewThreadRepoQuery = `query($id: ID!) {
+  node(id: $id) {
+    ... on PullRequestReviewThread {
+      repository { nameWithOwner }
+    }
+  }
+}`
+
+const reviewThreadReplyMutation = `mutation($threadId: ID!, $body: String!) {
+  addPullRequestReviewThreadReply(input: {pullRequestReviewThreadId: $threadId, body: $body}) {
+    comment { id author { login } body createdAt url }
+  }
+}`
+
+const resolveReviewThreadMutation = `mutation($threadId: ID!) {
+  resolveReviewThread(input: {threadId: $threadId}) {
+    thread { id isResolved }
+  }
+}`
+
+const unresolveReviewThreadMutation = `mutation($threadId: ID!) {
+  unresolveReviewThread(input: {threadId: $threadId}) {
+    thread { id isResolved }
+  }
+}`
+
+type reviewCommentPage struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []struct {
+		ReviewComment
+		DiffHunk string `json:"diffHunk"`
+	} `json:"nodes"`
+}
+
+func handlePRReviewThreads(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+	var params struct {
+		Repo   string `json:"repo"`
+		Number int    `json:"number"`
+		State  string `json:"state"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	owner, name, _ := strings.Cut(params.Repo, "/")
+
+	threads := []ReviewThread{}
+	cursor := ""
+
+	for {
+		variables := map[string]any{"owner": owner, "name": name, "number": params.Number}
+		if cursor != "" {
+			variables["endCursor"] = cursor
+		}
+
+		out, err := gh.Default().GraphQL(ctx, reviewThreadsQuery, variables)
+		if err != nil {
+			return ghErrorResult("gh api graphql review threads", err), nil
+		}
+
+		var resp struct {
+			Data struct {
+				Repository struct {
+					PullRequest *struct {
+						ReviewThreads struct {
+							PageInfo struct {
+								HasNextPage bool   `json:"hasNextPage"`
+								EndCursor   string `json:"endCursor"`
+							} `json:"pageInfo"`
+							Nodes []struct {
+								ReviewThread
+								Comments reviewCommentPage `json:"comments"`
+							} `json:"nodes"`
+						} `json:"reviewThreads"`
+					} `json:"pullRequest"`
+				} `json:"repository"`
+			} `json:"data"`
+		}
+
+		if err := json.Unmarshal(out.Body, &resp); err != nil {
+			return errorResult(kindInternal, "parsing review threads: %v", err), nil
+		}
+
+		pr := resp.Data.Repository.PullRequest
+		if pr == nil {
+			return errorResult(gh.KindNotFound, "pull request %s#%d not found", params.Repo, params.Number), nil
+		}
+
+		for _, node := range pr.ReviewThreads.Nodes {
+			thread := node.ReviewThread
+			if (params.State == "resolved" && !thread.IsResolved) || (params.State == "unresolved" && thread.IsResolved) {
+				continue
+			}
+
+			page := node.Comments
+
+			for {
+				for _, c := range page.Nodes {
+					if thread.DiffHunk == "" {
+						thread.DiffHunk = c.DiffHunk
+					}
+
+					thread.Comments = append(thread.Comments, c.ReviewComment)
+				}
+
+				if !page.PageInfo.HasNextPage {
+					break
+				}
+
+				page, err = reviewThreadComments(ctx, thread.ID, page.PageInfo.EndCursor)
+				if err != nil {
+					return ghErrorResult("gh api graphql review thread comments", err), nil
+				}
+			}
+
+			if thread.Comments == nil {
+				thread.Comments = []ReviewComment{}
+			}
+
+			threads = append(threads, thread)
+		}
+
+		if !pr.ReviewThreads.PageInfo.HasNextPage {
+			break
+		}
+
+		cursor = pr.ReviewThreads.PageInfo.EndCursor
+	}
+
+	resultJSON, err := json.MarshalIndent(threads, "", "  ")
+	if err != nil {
+		return errorResult(kindInternal, "marshaling review threads: %v", err), nil
+	}
+
+	return &protocol.ToolCallResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent(string(resultJSON)),
+		},
+	}, nil
+}
+
+// reviewThreadComments returns the page of a thread's comments after
+// cursor.
+func reviewThreadComments(ctx context.Context, threadID, cursor string) (reviewCommentPage, error) {
+	out, err := gh.Default().GraphQL(ctx, reviewThreadCommentsQuery, map[string]any{"id": threadID, "endCursor": cursor})
+	if err != nil {
+		return reviewCommentPage{}, err
+	}
+
+	var resp struct {
+		Data struct {
+			Node struct {
+				Comments reviewCommentPage `json:"comments"`
+			} `json:"node"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(out.Body, &resp); err != nil {
+		return reviewCommentPage{}, fmt.Errorf("parsing review thread comments: %w", err)
+	}
+
+	return resp.Data.Node.Comments, nil
+}
+
+// checkThreadRepo looks up the repository a review thread belongs to and
+// returns an error result unless it is repo. Thread IDs are opaque, so
+// this is what keeps the thread tools to the repositories the policy
+// allows.
+func checkThreadRepo(ctx context.Context, repo, threadID string) *protocol.ToolCallResult {
+	out, err := gh.Default().GraphQL(ctx, reviewThreadRepoQuery, map[string]any{"id": threadID})
+	if err != nil {
+		return ghErrorResult("gh api graphql review thread", err)
+	}
+
+	var resp struct {
+		Data struct {
+			Node *struct {
+				Repository *struct {
+					NameWithOwner string `json:"nameWithOwner"`
+				} `json:"repository"`
+			} `json:"node"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(out.Body, &resp); err != nil {
+		return errorResult(kindInternal, "parsing review thread: %v", err)
+	}
+
+	node := resp.Data.Node
+	if node == nil || node.Repository == nil {
+		return errorResult(gh.KindNotFound, "review thread %s not found", threadID)
+	}
+
+	if !strings.EqualFold(node.Repository.NameWithOwner, repo) {
+		return errorResult(gh.KindValidation, "review thread %s belongs to %s, not %s", threadID, node.Repository.NameWithOwner, repo)
+	}
+
+	return nil
+}
+
+func handlePRReviewThreadReply(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+	var params struct {
+		Repo     string `json:"repo"`
+		ThreadID string `json:"thread_id"`
+		Body     string `json:"body"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	if strings.TrimSpace(params.Body) == "" {
+		return errorResult(gh.KindValidation, "invalid arguments: body must not be empty"), nil
+	}
+
+	if result := checkThreadRepo(ctx, params.Repo, params.ThreadID); result != nil {
+		return result, nil
+	}
+
+	out, err := gh.Default().GraphQL(ctx, reviewThreadReplyMutation, map[string]any{"threadId": params.ThreadID, "body": params.Body})
+	if err != nil {
+		return ghErrorResult("gh api graphql review thread reply", err), nil
+	}
+
+	var resp struct {
+		Data struct {
+			AddPullRequestReviewThreadReply struct {
+				Comment ReviewComment `json:"comment"`
+			} `json:"addPullRequestReviewThreadReply"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(out.Body, &resp); err != nil {
+		return errorResult(kindInternal, "parsing reply: %v", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(resp.Data.AddPullRequestReviewThreadReply.Comment, "", "  ")
+	if err != nil {
+		return errorResult(kindInternal, "marshaling reply: %v", err), nil
+	}
+
+	return &protocol.ToolCallResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent(string(resultJSON)),
+		},
+	}, nil
+}
+
+// handleReviewThreadResolution returns the handler of pr_review_thread_resolve
+// or pr_review_thread_unresolve, which differ only in their mutation.
+func handleReviewThreadResolution(field, mutation string) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		var params struct {
+			Repo     string `json:"repo"`
+			ThreadID string `json:"thread_id"`
+		}
+
+		if err := json.Unmarshal(args, &params); err != nil {
+			return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+		}
+
+		if result := checkThreadRepo(ctx, params.Repo, params.ThreadID); result != nil {
+			return result, nil
+		}
+
+		out, err := gh.Default().GraphQL(ctx, mutation, map[string]any{"threadId": params.ThreadID})
+		if err != nil {
+			return ghErrorResult("gh api graphql "+field, err), nil
+		}
+
+		var resp struct {
+			Data map[string]struct {
+				Thread ReviewThreadState `json:"thread"`
+			} `json:"data"`
+		}
+
+		if err := json.Unmarshal(out.Body, &resp); err != nil {
+			return errorResult(kindInternal, "parsing %s response: %v", field, err), nil
+		}
+
+		resultJSON, err := json.MarshalIndent(resp.Data[field].Thread, "", "  ")
+		if err != nil {
+			return errorResult(kindInternal, "marshaling review thread: %v", err), nil
+		}
+
+		return &protocol.ToolCallResult{
+			Content: []protocol.ContentBlock{
+				protocol.TextContent(string(resultJSON)),
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// prTemplateQuery lists the directories GitHub reads pull request
+// templates from, one level deep so a PULL_REQUEST_TEMPLATE directory's
+// files come back too.
+const prTemplateQuery = `query($owner: String!, $name: String!, $github: String!, $root: String!, $docs: String!) {
+  repository(owner: $owner, name: $name) {
+    github: object(expression: $github) { ...templateDir }
+    root: object(expression: $root) { ...templateDir }
+    docs: object(expression: $docs) { ...templateDir }
+  }
+}
+
+fragment templateDir on GitObject {
+  ... on Tree {
+    entries {
+      name
+      type
+      object { ... on Tree { entries { name type } } }
+    }
+  }
+}`
+
+type treeEntry struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Object *struct {
+		Entries []treeEntry `json:"entries"`
+	} `json:"object"`
+}
+
+// prTemplates are the pull request templates of a repository: the single
+// template file, in the order GitHub prefers them, and the files of
+// PULL_REQUEST_TEMPLATE directories.
+type prTemplates struct {
+	defaults []string
+	named    []string
+}
+
+// isTemplateName reports whether name is the template file or directory
+// name GitHub looks for: pull_request_template in any case, with an
+// optional .md or .txt extension.
+func isTemplateName(name string, allowExt bool) bool {
+	base := name
+
+	if ext := path.Ext(name); allowExt && (strings.EqualFold(ext, ".md") || strings.EqualFold(ext, ".txt")) {
+		base = strings.TrimSuffix(name, ext)
+	}
+
+	return strings.EqualFold(base, "pull_request_template")
+}
+
+// listPRTemplates finds the pull request templates of repo at ref, or at
+// the default branch when ref is empty.
+func listPRTemplates(ctx context.Context, repo, ref string) (prTemplates, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	owner, name, _ := strings.Cut(repo, "/")
+
+	resp, err := gh.Default().GraphQL(ctx, prTemplateQuery, map[string]any{
+		"owner":  owner,
+		"name":   name,
+		"github": ref + ":.github",
+		"root":   ref + ":",
+		"docs":   ref + ":docs",
+	})
+	if err != nil {
+		return prTemplates{}, err
+	}
+
+	type dir struct {
+		Entries []treeEntry `json:"entries"`
+	}
+
+	var payload struct {
+		Data struct {
+			Repository *struct {
+				GitHub *dir `json:"github"`
+				Root   *dir `json:"root"`
+				Docs   *dir `json:"docs"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(resp.Body, &payload); err != nil {
+		return prTemplates{}, fmt.Errorf("parsing pull request templates: %w", err)
+	}
+
+	r := payload.Data.Repository
+	if r == nil {
+		return prTemplates{}, &gh.Error{Kind: gh.KindNotFound, Op: "graphql", Message: fmt.Sprintf("repository %s not found", repo)}
+	}
+
+	var templates prTemplates
+
+	for _, d := range []struct {
+		path string
+		dir  *dir
+	}{{".github", r.GitHub}, {"", r.Root}, {"docs", r.Docs}} {
+		if d.dir == nil {
+			continue
+		}
+
+		for _, entry := range d.dir.Entries {
+			switch {
+			case entry.Type == "blob" && isTemplateName(entry.Name, true):
+				templates.defaults = append(templates.defaults, path.Join(d.path, entry.Name))
+
+			case entry.Type == "tree" && isTemplateName(entry.Name, false) && entry.Object != nil:
+				for _, file := range entry.Object.Entries {
+					if file.Type == "blob" && strings.EqualFold(path.Ext(file.Name), ".md") {
+						templates.named = append(templates.named, path.Join(d.path, entry.Name, file.Name))
+					}
+				}
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+// pick returns the path of the template to use: the one named, else the
+// single template file, else the only file of a template directory. A
+// choice between several directory templates is left to the caller.
+func (t prTemplates) pick(name string) (string, error) {
+	if name != "" {
+		for _, p := range t.named {
+			base := path.Base(p)
+			if strings.EqualFold(base, name) || strings.EqualFold(strings.TrimSuffix(base, path.Ext(base)), name) {
+				return p, nil
+			}
+		}
+
+		return "", fmt.Errorf("no pull request template named %q (available: %s)", name, t.names())
+	}
+
+	switch {
+	case len(t.defaults) > 0:
+		return t.defaults[0], nil
+	case len(t.named) == 1:
+		return t.named[0], nil
+	case len(t.named) > 1:
+		return "", fmt.Errorf("repository has several pull request templates (%s); pass template to choose one or body to write your own", t.names())
+	}
+
+	return "", nil
+}
+
+func (t prTemplates) names() string {
+	if len(t.named) == 0 {
+		return "none"
+	}
+
+	names := make([]string, len(t.named))
+	for i, p := range t.named {
+		names[i] = path.Base(p)
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+func registerRateLimitTools(r *toolRegistry) {
+	r.Register(
+		"rate_limit_status",
+		"Show the remaining GitHub API rate limit budgets (REST core, GraphQL, search, code search). Checking does not count against any limit",
+		json.RawMessage(`{
+			"type": "object",
+			"properties": {}
+		}`),
+		handleRateLimitStatus,
+		structured[map[string]RateLimitBudget](),
+	)
+}
+
+func handleRateLimitStatus(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+	resp, err := gh.Default().REST(ctx, gh.Request{Method: http.MethodGet, Path: "rate_limit"})
+	if err != nil {
+		return ghErrorResult("gh api rate_limit", err), nil
+	}
+
+	var payload struct {
+		Resources map[string]struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Used      int   `json:"used"`
+			Reset     int64 `json:"reset"`
+		} `json:"resources"`
+	}
+
+	if err := json.Unmarshal(resp.Body, &payload); err != nil {
+		return errorResult(kindInternal, "parsing rate_limit response: %v", err), nil
+	}
+
+	result := make(map[string]RateLimitBudget, len(payload.Resources))
+	for name, res := range payload.Resources {
+		result[name] = RateLimitBudget{
+			Limit:     res.Limit,
+			Remaining: res.Remaining,
+			Used:      res.Used,
+			Reset:     time.Unix(res.Reset, 0).UTC().Format(time.RFC3339),
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(kindInternal, "marshaling rate limits: %v", err), nil
+	}
+
+	return &protocol.ToolCallResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent(string(resultJSON)),
+		},
+	}, nil
+}
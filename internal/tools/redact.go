@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/redact"
+)
+
+// withRedaction masks credentials in everything a handler returns,
+// including error results, before it reaches the client.
+func withRedaction(r *redact.Redactor, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		result, err := handler(ctx, args)
+		if err != nil {
+			return result, errors.New(r.Redact(err.Error()))
+		}
+
+		if result != nil {
+			for i := range result.Content {
+				result.Content[i].Text = r.Redact(result.Content[i].Text)
+			}
+		}
+
+		return result, nil
+	}
+}
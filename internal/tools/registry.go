@@ -1,16 +1,266 @@
 package tools
 
-import "github.com/amarbel-llc/go-lib-mcp/server"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 
-func RegisterAll() *server.ToolRegistry {
-	r := server.NewToolRegistry()
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/amarbel-llc/go-lib-mcp/server"
+	"github.com/friedenberg/get-hubbed/internal/audit"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+	"github.com/friedenberg/get-hubbed/internal/policy"
+	"github.com/friedenberg/get-hubbed/internal/redact"
+)
 
-	registerRepoTools(r)
-	registerIssueTools(r)
-	registerPRTools(r)
-	registerAPITools(r)
-	registerRunTools(r)
-	registerContentTools(r)
+type Options struct {
+	// ReadOnly leaves out every tool that can modify state on GitHub.
+	ReadOnly bool
 
-	return r
+	// Toolsets limits registration to the named groups; empty means all.
+	// ExcludeToolsets is applied afterwards.
+	Toolsets        []string
+	ExcludeToolsets []string
+
+	// Policy, when active, restricts which repositories and owners any
+	// tool may name.
+	Policy *policy.Policy
+
+	// DefaultRepo is used by tools that take a repo when a call omits it.
+	// It may be given as HOST/OWNER/REPO.
+	DefaultRepo string
+
+	// DefaultHost is the GitHub host for calls that do not name one; empty
+	// leaves the choice to gh (GH_HOST, or github.com).
+	DefaultHost string
+
+	// Hosts lists the hosts besides DefaultHost that a call may name. The
+	// host of a HOST/OWNER/REPO DefaultRepo is added to it; any other host
+	// is refused, so credentials are only ever sent where configured.
+	Hosts []string
+
+	// Audit, when set, receives an entry for every tool call.
+	Audit *audit.Log
+
+	// Redactor, when set, masks credentials in all tool output.
+	Redactor *redact.Redactor
+
+	// OutputBudget caps the bytes a single call returns; longer output is
+	// chunked behind a continuation cursor. ToolOutputBudgets overrides
+	// it per tool name. Zero means unlimited.
+	OutputBudget      int
+	ToolOutputBudgets map[string]int
+
+	// OutputFormat is the result format for calls that do not set
+	// output_format; empty means FormatJSON.
+	OutputFormat string
+}
+
+func (o Options) budgetFor(name string) int {
+	if budget, ok := o.ToolOutputBudgets[name]; ok {
+		return budget
+	}
+
+	return o.OutputBudget
+}
+
+// hosts returns the normalized hosts a call may name, sorted.
+func (o Options) hosts() []string {
+	defaultHost := o.DefaultHost
+	if defaultHost == "" {
+		defaultHost = policy.DefaultHost
+	}
+
+	hosts := append([]string{defaultHost}, o.Hosts...)
+
+	if repoHost, _ := splitHostRepo(o.DefaultRepo); repoHost != "" {
+		hosts = append(hosts, repoHost)
+	}
+
+	for i, host := range hosts {
+		hosts[i] = gh.NormalizeHost(host)
+	}
+
+	slices.Sort(hosts)
+
+	return slices.Compact(hosts)
+}
+
+func (o Options) toolsetEnabled(name string) bool {
+	if len(o.Toolsets) > 0 && !slices.Contains(o.Toolsets, name) {
+		return false
+	}
+
+	return !slices.Contains(o.ExcludeToolsets, name)
+}
+
+var toolsets = []struct {
+	name     string
+	register func(*toolRegistry)
+}{
+	{"repo", registerRepoTools},
+	{"issue", registerIssueTools},
+	{"pr", registerPRTools},
+	{"api", registerAPITools},
+	{"rate_limit", registerRateLimitTools},
+	{"run", registerRunTools},
+	{"content", registerContentTools},
+}
+
+func ToolsetNames() []string {
+	names := make([]string, len(toolsets))
+	for i, ts := range toolsets {
+		names[i] = ts.name
+	}
+
+	return names
+}
+
+type handlerFunc = func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error)
+
+type tool struct {
+	name        string
+	description string
+	schema      json.RawMessage
+	handler     handlerFunc
+	mutating    bool
+	destructive bool
+	idempotent  bool
+	columns     []string
+
+	// outputSchema and decode are set for tools with a structured result
+	// type; see structured.
+	outputSchema json.RawMessage
+	decode       func(string) (json.RawMessage, error)
+}
+
+type toolOption func(*tool)
+
+// mutating marks a tool that writes to GitHub.
+func mutating(t *tool) {
+	t.mutating = true
+}
+
+// toolRegistry records every tool it forwards to the MCP registry so the
+// full tool set can be inspected without going through the server.
+type toolRegistry struct {
+	inner   *server.ToolRegistry
+	opts    Options
+	hosts   []string
+	tools   []tool
+	outputs *outputStore
+}
+
+func newToolRegistry(opts Options) *toolRegistry {
+	return &toolRegistry{inner: server.NewToolRegistry(), opts: opts, hosts: opts.hosts(), outputs: newOutputStore()}
+}
+
+func (r *toolRegistry) Register(name, description string, schema json.RawMessage, handler handlerFunc, options ...toolOption) {
+	t := tool{
+		name:        name,
+		description: description,
+		schema:      schema,
+		handler:     handler,
+	}
+
+	for _, option := range options {
+		option(&t)
+	}
+
+	if r.opts.ReadOnly && t.mutating {
+		return
+	}
+
+	if t.decode != nil {
+		t.handler = withStructuredDecode(t.name, t.decode, t.handler)
+	}
+
+	if r.opts.Policy.Active() {
+		t.handler = withPolicy(r.opts.Policy, policyScopeOf(t.schema), t.handler)
+	}
+
+	t.handler = withHost(r.opts.DefaultHost, r.hosts, t.handler)
+
+	if takesRepo(t.schema) {
+		t.handler = withDefaultRepo(r.opts.DefaultRepo, t.handler)
+
+		if r.opts.DefaultRepo != "" {
+			t.schema = describeDefaultRepo(t.schema, r.opts.DefaultRepo)
+		}
+	}
+
+	format := r.opts.OutputFormat
+	if format == "" {
+		format = FormatJSON
+	}
+
+	t.handler = withOutputFormat(format, t.columns, t.handler)
+	t.schema = addOutputFormatProperty(t.schema, format)
+
+	if r.opts.Redactor != nil {
+		t.handler = withRedaction(r.opts.Redactor, t.handler)
+	}
+
+	if budget := r.opts.budgetFor(t.name); budget > 0 {
+		t.handler = withBudget(t.name, budget, r.outputs, t.handler)
+		t.schema = addCursorProperty(t.schema)
+	}
+
+	t.schema = addHostProperty(t.schema, r.opts.DefaultHost, r.hosts)
+	t.handler = withValidation(t.schema, t.handler)
+
+	if r.opts.Audit != nil {
+		t.handler = withAudit(r.opts.Audit, r.opts.Redactor, t.name, t.handler)
+	}
+
+	if t.decode != nil {
+		t.handler = withStructuredContent(r.opts.Redactor, t.handler)
+	}
+
+	r.tools = append(r.tools, t)
+	r.inner.Register(t.name, t.description, t.schema, t.handler)
+}
+
+func RegisterAll(opts Options) (*server.ToolRegistry, error) {
+	known := ToolsetNames()
+
+	for _, name := range slices.Concat(opts.Toolsets, opts.ExcludeToolsets) {
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unknown toolset %q (available: %s)", name, strings.Join(known, ", "))
+		}
+	}
+
+	if opts.OutputFormat != "" && !slices.Contains(outputFormats, opts.OutputFormat) {
+		return nil, fmt.Errorf("unknown output format %q (available: %s)", opts.OutputFormat, strings.Join(outputFormats, ", "))
+	}
+
+	if opts.DefaultRepo != "" {
+		if err := validateRepo(opts.DefaultRepo); err != nil {
+			return nil, fmt.Errorf("default repository: %w", err)
+		}
+	}
+
+	all := newToolRegistry(Options{})
+	registerTools(all)
+
+	for name := range opts.ToolOutputBudgets {
+		if !slices.ContainsFunc(all.tools, func(t tool) bool { return t.name == name }) {
+			return nil, fmt.Errorf("output budget for unknown tool %q", name)
+		}
+	}
+
+	r := newToolRegistry(opts)
+	registerTools(r)
+
+	return r.inner, nil
+}
+
+func registerTools(r *toolRegistry) {
+	for _, ts := range toolsets {
+		if r.opts.toolsetEnabled(ts.name) {
+			ts.register(r)
+		}
+	}
 }
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func registeredNames(opts Options) []string {
+	r := newToolRegistry(opts)
+	registerTools(r)
+
+	names := make([]string, len(r.tools))
+	for i, t := range r.tools {
+		names[i] = t.name
+	}
+
+	return names
+}
+
+func TestReadOnlyOmitsMutatingTools(t *testing.T) {
+	all := registeredNames(Options{})
+	readOnly := registeredNames(Options{ReadOnly: true})
+
+	for _, name := range []string{"issue_create", "pr_create", "pr_review", "pr_review_thread_resolve", "graphql_mutation"} {
+		if !slices.Contains(all, name) {
+			t.Errorf("%s missing from default registry", name)
+		}
+
+		if slices.Contains(readOnly, name) {
+			t.Errorf("%s registered in read-only mode", name)
+		}
+	}
+
+	for _, name := range []string{"api_get", "graphql_query", "content_read"} {
+		if !slices.Contains(readOnly, name) {
+			t.Errorf("%s missing from read-only registry", name)
+		}
+	}
+}
+
+func TestToolsetSelection(t *testing.T) {
+	names := registeredNames(Options{
+		Toolsets:        []string{"content", "run"},
+		ExcludeToolsets: []string{"run"},
+	})
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, "content_") {
+			t.Errorf("unexpected tool %s", name)
+		}
+	}
+
+	if !slices.Contains(names, "content_read") {
+		t.Error("content_read missing")
+	}
+}
+
+func TestRegisterAllRejectsUnknownToolset(t *testing.T) {
+	if _, err := RegisterAll(Options{ExcludeToolsets: []string{"gists"}}); err == nil {
+		t.Error("expected error for unknown toolset")
+	}
+}
+
+func TestRegisterAllRejectsUnknownOutputFormat(t *testing.T) {
+	if _, err := RegisterAll(Options{OutputFormat: "xml"}); err == nil {
+		t.Error("expected error for unknown output format")
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	annotations := Annotations()
+
+	for name, a := range annotations {
+		isRead := strings.HasPrefix(name, "content_") ||
+			strings.HasSuffix(name, "_list") ||
+			strings.HasSuffix(name, "_view") ||
+			name == "api_get" || name == "graphql_query"
+
+		if isRead && (!a.ReadOnlyHint || a.DestructiveHint) {
+			t.Errorf("%s should be read-only: %+v", name, a)
+		}
+	}
+
+	if a := annotations["issue_create"]; a.ReadOnlyHint || a.IdempotentHint || a.DestructiveHint {
+		t.Errorf("issue_create should be a non-idempotent, non-destructive write: %+v", a)
+	}
+
+	if a := annotations["pr_review_thread_resolve"]; a.ReadOnlyHint || !a.IdempotentHint {
+		t.Errorf("pr_review_thread_resolve should be an idempotent write: %+v", a)
+	}
+
+	if a := annotations["graphql_mutation"]; a.ReadOnlyHint || !a.DestructiveHint {
+		t.Errorf("graphql_mutation should be destructive: %+v", a)
+	}
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/amarbel-llc/go-lib-mcp/protocol"
-	"github.com/amarbel-llc/go-lib-mcp/server"
 	"github.com/friedenberg/get-hubbed/internal/gh"
 )
 
-func registerRepoTools(r *server.ToolRegistry) {
+func registerRepoTools(r *toolRegistry) {
 	r.Register(
 		"repo_view",
 		"View repository details",
@@ -19,12 +19,18 @@ func registerRepoTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh repo view --json supports"
 				}
-			},
-			"required": ["repo"]
+			}
 		}`),
 		handleRepoView,
+		structured[Repository](),
 	)
 
 	r.Register(
@@ -35,65 +41,167 @@ func registerRepoTools(r *server.ToolRegistry) {
 			"properties": {
 				"owner": {
 					"type": "string",
-					"description": "GitHub user or organization"
+					"description": "GitHub user or organization",
+					"format": "owner"
 				},
 				"limit": {
 					"type": "integer",
-					"description": "Maximum number of repositories to list (default 30)"
+					"description": "Maximum number of repositories to list (default 30)",
+					"minimum": 1
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh repo list --json supports"
+				},
+				"jq": {
+					"type": "string",
+					"description": "jq expression gh applies to the JSON output before returning it, e.g. '.[] | {number, title}'"
 				}
 			},
 			"required": ["owner"]
 		}`),
 		handleRepoList,
+		structured[[]Repository](),
 	)
 }
 
 func handleRepoView(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
-		Repo string `json:"repo"`
+		Repo   string   `json:"repo"`
+		Fields []string `json:"fields"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
-	out, err := gh.Run(ctx,
-		"repo", "view", params.Repo,
-		"--json", "name,owner,description,url,defaultBranchRef,stargazerCount,forkCount,isPrivate,createdAt,updatedAt",
-	)
+	// The REST response only covers the default fields; anything else comes
+	// from gh's GraphQL-backed view.
+	if len(params.Fields) > 0 {
+		fields, err := selectFields(params.Fields, repoFields, "")
+		if err != nil {
+			return errorResult(gh.KindValidation, "%v", err), nil
+		}
+
+		out, err := gh.Run(ctx, "repo", "view", params.Repo, "--json", fields)
+		if err != nil {
+			return ghErrorResult("gh repo view", err), nil
+		}
+
+		return &protocol.ToolCallResult{
+			Content: []protocol.ContentBlock{
+				protocol.TextContent(out),
+			},
+		}, nil
+	}
+
+	// REST rather than `gh repo view` so the response carries an ETag the
+	// response cache can revalidate.
+	resp, err := gh.Default().REST(ctx, gh.Request{
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("repos/%s", params.Repo),
+	})
+	if err != nil {
+		return ghErrorResult("gh api repos", err), nil
+	}
+
+	var repo struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+		Description     string `json:"description"`
+		HTMLURL         string `json:"html_url"`
+		DefaultBranch   string `json:"default_branch"`
+		StargazersCount int    `json:"stargazers_count"`
+		ForksCount      int    `json:"forks_count"`
+		Private         bool   `json:"private"`
+		CreatedAt       string `json:"created_at"`
+		UpdatedAt       string `json:"updated_at"`
+	}
+
+	if err := json.Unmarshal(resp.Body, &repo); err != nil {
+		return errorResult(kindInternal, "parsing repository response: %v", err), nil
+	}
+
+	type login struct {
+		Login string `json:"login"`
+	}
+
+	type branchRef struct {
+		Name string `json:"name"`
+	}
+
+	result := struct {
+		Name             string    `json:"name"`
+		Owner            login     `json:"owner"`
+		Description      string    `json:"description"`
+		URL              string    `json:"url"`
+		DefaultBranchRef branchRef `json:"defaultBranchRef"`
+		StargazerCount   int       `json:"stargazerCount"`
+		ForkCount        int       `json:"forkCount"`
+		IsPrivate        bool      `json:"isPrivate"`
+		CreatedAt        string    `json:"createdAt"`
+		UpdatedAt        string    `json:"updatedAt"`
+	}{
+		Name:             repo.Name,
+		Owner:            login{Login: repo.Owner.Login},
+		Description:      repo.Description,
+		URL:              repo.HTMLURL,
+		DefaultBranchRef: branchRef{Name: repo.DefaultBranch},
+		StargazerCount:   repo.StargazersCount,
+		ForkCount:        repo.ForksCount,
+		IsPrivate:        repo.Private,
+		CreatedAt:        repo.CreatedAt,
+		UpdatedAt:        repo.UpdatedAt,
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh repo view: %v", err)), nil
+		return errorResult(kindInternal, "marshaling repository: %v", err), nil
 	}
 
 	return &protocol.ToolCallResult{
 		Content: []protocol.ContentBlock{
-			protocol.TextContent(out),
+			protocol.TextContent(string(resultJSON)),
 		},
 	}, nil
 }
 
 func handleRepoList(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
-		Owner string `json:"owner"`
-		Limit int    `json:"limit"`
+		Owner  string   `json:"owner"`
+		Limit  int      `json:"limit"`
+		Fields []string `json:"fields"`
+		JQ     string   `json:"jq"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	fields, err := selectFields(params.Fields, repoFields, "name,owner,description,url,isPrivate,stargazerCount,updatedAt")
+	if err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
 	ghArgs := []string{
 		"repo", "list", params.Owner,
-		"--json", "name,owner,description,url,isPrivate,stargazerCount,updatedAt",
+		"--json", fields,
 	}
 
 	if params.Limit > 0 {
 		ghArgs = append(ghArgs, "--limit", fmt.Sprintf("%d", params.Limit))
 	}
 
+	if params.JQ != "" {
+		ghArgs = append(ghArgs, "--jq", params.JQ)
+	}
+
 	out, err := gh.Run(ctx, ghArgs...)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh repo list: %v", err)), nil
+		return ghErrorResult("gh repo list", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -0,0 +1,507 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Result types for tools with structured output. Fields follow the JSON
+// gh and the REST API print. Tools whose fields a call can choose leave
+// every field optional; the rest require what their projection always
+// returns.
+
+type Actor struct {
+	Login string `json:"login"`
+	Name  string `json:"name,omitempty"`
+	IsBot bool   `json:"is_bot,omitempty"`
+}
+
+type Label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type Milestone struct {
+	Number      int    `json:"number,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	DueOn       string `json:"dueOn,omitempty"`
+}
+
+type Comment struct {
+	Author    *Actor `json:"author,omitempty"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type Issue struct {
+	ID          string     `json:"id,omitempty"`
+	Number      int        `json:"number,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	State       string     `json:"state,omitempty"`
+	StateReason string     `json:"stateReason,omitempty"`
+	Body        string     `json:"body,omitempty"`
+	Author      *Actor     `json:"author,omitempty"`
+	Labels      []Label    `json:"labels,omitempty"`
+	Assignees   []Actor    `json:"assignees,omitempty"`
+	Milestone   *Milestone `json:"milestone,omitempty"`
+	Comments    []Comment  `json:"comments,omitempty"`
+	IsPinned    bool       `json:"isPinned,omitempty"`
+	Closed      bool       `json:"closed,omitempty"`
+	ClosedAt    string     `json:"closedAt,omitempty"`
+	CreatedAt   string     `json:"createdAt,omitempty"`
+	UpdatedAt   string     `json:"updatedAt,omitempty"`
+	URL         string     `json:"url,omitempty"`
+
+	// Passed through as gh prints them.
+	ProjectCards   json.RawMessage `json:"projectCards,omitempty"`
+	ProjectItems   json.RawMessage `json:"projectItems,omitempty"`
+	ReactionGroups json.RawMessage `json:"reactionGroups,omitempty"`
+}
+
+// CreatedIssue is the issue issue_create opened.
+type CreatedIssue struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+type PullRequestCommit struct {
+	OID             string `json:"oid"`
+	MessageHeadline string `json:"messageHeadline,omitempty"`
+	MessageBody     string `json:"messageBody,omitempty"`
+	AuthoredDate    string `json:"authoredDate,omitempty"`
+	CommittedDate   string `json:"committedDate,omitempty"`
+}
+
+// StatusCheck is an entry of statusCheckRollup: a check run, which has a
+// name, status and conclusion, or a commit status, which has a context
+// and state.
+type StatusCheck struct {
+	Typename     string `json:"__typename,omitempty"`
+	Name         string `json:"name,omitempty"`
+	WorkflowName string `json:"workflowName,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Conclusion   string `json:"conclusion,omitempty"`
+	Context      string `json:"context,omitempty"`
+	State        string `json:"state,omitempty"`
+	DetailsURL   string `json:"detailsUrl,omitempty"`
+	TargetURL    string `json:"targetUrl,omitempty"`
+}
+
+// DiffStatFile is an entry of a pull request's files as gh pr view
+// reports them.
+type DiffStatFile struct {
+	Path       string `json:"path"`
+	Additions  int    `json:"additions"`
+	Deletions  int    `json:"deletions"`
+	ChangeType string `json:"changeType,omitempty"`
+}
+
+// Review is a submitted pull request review.
+type Review struct {
+	ID                string `json:"id,omitempty"`
+	Author            *Actor `json:"author,omitempty"`
+	AuthorAssociation string `json:"authorAssociation,omitempty"`
+	Body              string `json:"body"`
+	State             string `json:"state"`
+	SubmittedAt       string `json:"submittedAt,omitempty"`
+	Commit            *struct {
+		OID string `json:"oid"`
+	} `json:"commit,omitempty"`
+}
+
+// ReviewRequest is a pending review request, for a user (Login) or a team
+// (Name and Slug), told apart by Typename.
+type ReviewRequest struct {
+	Typename string `json:"__typename,omitempty"`
+	Login    string `json:"login,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Slug     string `json:"slug,omitempty"`
+}
+
+type PullRequest struct {
+	ID                  string              `json:"id,omitempty"`
+	FullDatabaseID      json.RawMessage     `json:"fullDatabaseId,omitempty"`
+	Number              int                 `json:"number,omitempty"`
+	Title               string              `json:"title,omitempty"`
+	State               string              `json:"state,omitempty"`
+	IsDraft             bool                `json:"isDraft,omitempty"`
+	Body                string              `json:"body,omitempty"`
+	Author              *Actor              `json:"author,omitempty"`
+	BaseRefName         string              `json:"baseRefName,omitempty"`
+	BaseRefOid          string              `json:"baseRefOid,omitempty"`
+	HeadRefName         string              `json:"headRefName,omitempty"`
+	HeadRefOid          string              `json:"headRefOid,omitempty"`
+	IsCrossRepository   bool                `json:"isCrossRepository,omitempty"`
+	HeadRepository      json.RawMessage     `json:"headRepository,omitempty"`
+	HeadRepositoryOwner *Actor              `json:"headRepositoryOwner,omitempty"`
+	MaintainerCanModify bool                `json:"maintainerCanModify,omitempty"`
+	Labels              []Label             `json:"labels,omitempty"`
+	Assignees           []Actor             `json:"assignees,omitempty"`
+	Milestone           *Milestone          `json:"milestone,omitempty"`
+	ReviewDecision      string              `json:"reviewDecision,omitempty"`
+	Mergeable           string              `json:"mergeable,omitempty"`
+	MergeStateStatus    string              `json:"mergeStateStatus,omitempty"`
+	Additions           int                 `json:"additions,omitempty"`
+	Deletions           int                 `json:"deletions,omitempty"`
+	ChangedFiles        int                 `json:"changedFiles,omitempty"`
+	Commits             []PullRequestCommit `json:"commits,omitempty"`
+	Comments            []Comment           `json:"comments,omitempty"`
+	Files               []DiffStatFile      `json:"files,omitempty"`
+	Reviews             []Review            `json:"reviews,omitempty"`
+	LatestReviews       []Review            `json:"latestReviews,omitempty"`
+	ReviewRequests      []ReviewRequest     `json:"reviewRequests,omitempty"`
+	StatusCheckRollup   []StatusCheck       `json:"statusCheckRollup,omitempty"`
+	Closed              bool                `json:"closed,omitempty"`
+	ClosedAt            string              `json:"closedAt,omitempty"`
+	MergedAt            string              `json:"mergedAt,omitempty"`
+	MergedBy            *Actor              `json:"mergedBy,omitempty"`
+	CreatedAt           string              `json:"createdAt,omitempty"`
+	UpdatedAt           string              `json:"updatedAt,omitempty"`
+	URL                 string              `json:"url,omitempty"`
+
+	// Passed through as gh prints them.
+	AutoMergeRequest        json.RawMessage `json:"autoMergeRequest,omitempty"`
+	ClosingIssuesReferences json.RawMessage `json:"closingIssuesReferences,omitempty"`
+	MergeCommit             json.RawMessage `json:"mergeCommit,omitempty"`
+	PotentialMergeCommit    json.RawMessage `json:"potentialMergeCommit,omitempty"`
+	ProjectCards            json.RawMessage `json:"projectCards,omitempty"`
+	ProjectItems            json.RawMessage `json:"projectItems,omitempty"`
+	ReactionGroups          json.RawMessage `json:"reactionGroups,omitempty"`
+}
+
+// CreatedPullRequest is the pull request pr_create opened.
+type CreatedPullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// PullRequestFile is a file a pull request changes. Generated is set for
+// files .gitattributes marks linguist-generated or linguist-vendored and
+// for common lockfiles, vendored directories and generated code, with
+// GeneratedReason saying which.
+type PullRequestFile struct {
+	Path            string `json:"path"`
+	PreviousPath    string `json:"previous_path,omitempty"`
+	ChangeType      string `json:"change_type"`
+	Additions       int    `json:"additions"`
+	Deletions       int    `json:"deletions"`
+	Generated       bool   `json:"generated"`
+	GeneratedReason string `json:"generated_reason,omitempty"`
+}
+
+// PullRequestFiles lists a pull request's files. Rename sources come from
+// the REST API, which stops at 3000 files; PreviousPathsTruncated is set
+// when renamed files past that point have no PreviousPath.
+type PullRequestFiles struct {
+	Entries                []PullRequestFile `json:"entries"`
+	Total                  int               `json:"total"`
+	Additions              int               `json:"additions"`
+	Deletions              int               `json:"deletions"`
+	Generated              int               `json:"generated"`
+	PreviousPathsTruncated bool              `json:"previous_paths_truncated,omitempty"`
+}
+
+// SubmittedReview is the review pr_review submitted.
+type SubmittedReview struct {
+	ID          int64  `json:"id"`
+	State       string `json:"state"`
+	URL         string `json:"url"`
+	SubmittedAt string `json:"submittedAt,omitempty"`
+	Comments    int    `json:"comments"`
+}
+
+// ReviewComment is a comment in a review thread.
+type ReviewComment struct {
+	ID        string `json:"id"`
+	Author    *Actor `json:"author,omitempty"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// ReviewThread is a thread of inline review comments. Line and StartLine
+// are where the thread sits in the pull request's current diff and are
+// unset once it is outdated; OriginalLine is where it was first placed.
+// DiffHunk is the diff context the first comment was made on.
+type ReviewThread struct {
+	ID           string          `json:"id"`
+	Path         string          `json:"path"`
+	Line         int             `json:"line,omitempty"`
+	StartLine    int             `json:"startLine,omitempty"`
+	OriginalLine int             `json:"originalLine,omitempty"`
+	DiffSide     string          `json:"diffSide"`
+	IsResolved   bool            `json:"isResolved"`
+	IsOutdated   bool            `json:"isOutdated"`
+	ResolvedBy   *Actor          `json:"resolvedBy,omitempty"`
+	DiffHunk     string          `json:"diffHunk"`
+	Comments     []ReviewComment `json:"comments"`
+}
+
+// ReviewThreadState is a thread's resolution after resolving or
+// unresolving it.
+type ReviewThreadState struct {
+	ID         string `json:"id"`
+	IsResolved bool   `json:"isResolved"`
+}
+
+type RefName struct {
+	Name string `json:"name"`
+}
+
+// Count is a connection gh reports by its size alone.
+type Count struct {
+	TotalCount int `json:"totalCount"`
+}
+
+type Repository struct {
+	ID                            string   `json:"id,omitempty"`
+	Name                          string   `json:"name,omitempty"`
+	NameWithOwner                 string   `json:"nameWithOwner,omitempty"`
+	Owner                         *Actor   `json:"owner,omitempty"`
+	Description                   string   `json:"description,omitempty"`
+	URL                           string   `json:"url,omitempty"`
+	SSHURL                        string   `json:"sshUrl,omitempty"`
+	MirrorURL                     string   `json:"mirrorUrl,omitempty"`
+	HomepageURL                   string   `json:"homepageUrl,omitempty"`
+	OpenGraphImageURL             string   `json:"openGraphImageUrl,omitempty"`
+	UsesCustomOpenGraphImage      bool     `json:"usesCustomOpenGraphImage,omitempty"`
+	SecurityPolicyURL             string   `json:"securityPolicyUrl,omitempty"`
+	DefaultBranchRef              *RefName `json:"defaultBranchRef,omitempty"`
+	PrimaryLanguage               *RefName `json:"primaryLanguage,omitempty"`
+	Labels                        []Label  `json:"labels,omitempty"`
+	StargazerCount                int      `json:"stargazerCount,omitempty"`
+	ForkCount                     int      `json:"forkCount,omitempty"`
+	DiskUsage                     int      `json:"diskUsage,omitempty"`
+	Issues                        *Count   `json:"issues,omitempty"`
+	PullRequests                  *Count   `json:"pullRequests,omitempty"`
+	Watchers                      *Count   `json:"watchers,omitempty"`
+	IsPrivate                     bool     `json:"isPrivate,omitempty"`
+	IsArchived                    bool     `json:"isArchived,omitempty"`
+	IsFork                        bool     `json:"isFork,omitempty"`
+	IsMirror                      bool     `json:"isMirror,omitempty"`
+	IsTemplate                    bool     `json:"isTemplate,omitempty"`
+	IsEmpty                       bool     `json:"isEmpty,omitempty"`
+	IsInOrganization              bool     `json:"isInOrganization,omitempty"`
+	IsUserConfigurationRepository bool     `json:"isUserConfigurationRepository,omitempty"`
+	IsBlankIssuesEnabled          bool     `json:"isBlankIssuesEnabled,omitempty"`
+	IsSecurityPolicyEnabled       bool     `json:"isSecurityPolicyEnabled,omitempty"`
+	HasIssuesEnabled              bool     `json:"hasIssuesEnabled,omitempty"`
+	HasProjectsEnabled            bool     `json:"hasProjectsEnabled,omitempty"`
+	HasWikiEnabled                bool     `json:"hasWikiEnabled,omitempty"`
+	HasDiscussionsEnabled         bool     `json:"hasDiscussionsEnabled,omitempty"`
+	MergeCommitAllowed            bool     `json:"mergeCommitAllowed,omitempty"`
+	RebaseMergeAllowed            bool     `json:"rebaseMergeAllowed,omitempty"`
+	SquashMergeAllowed            bool     `json:"squashMergeAllowed,omitempty"`
+	DeleteBranchOnMerge           bool     `json:"deleteBranchOnMerge,omitempty"`
+	Visibility                    string   `json:"visibility,omitempty"`
+	ViewerCanAdminister           bool     `json:"viewerCanAdminister,omitempty"`
+	ViewerHasStarred              bool     `json:"viewerHasStarred,omitempty"`
+	ViewerPermission              string   `json:"viewerPermission,omitempty"`
+	ViewerSubscription            string   `json:"viewerSubscription,omitempty"`
+	ViewerDefaultMergeMethod      string   `json:"viewerDefaultMergeMethod,omitempty"`
+	ViewerDefaultCommitEmail      string   `json:"viewerDefaultCommitEmail,omitempty"`
+	ViewerPossibleCommitEmails    []string `json:"viewerPossibleCommitEmails,omitempty"`
+	CreatedAt                     string   `json:"createdAt,omitempty"`
+	UpdatedAt                     string   `json:"updatedAt,omitempty"`
+	PushedAt                      string   `json:"pushedAt,omitempty"`
+	ArchivedAt                    string   `json:"archivedAt,omitempty"`
+
+	// Passed through as gh prints them.
+	AssignableUsers      json.RawMessage `json:"assignableUsers,omitempty"`
+	MentionableUsers     json.RawMessage `json:"mentionableUsers,omitempty"`
+	CodeOfConduct        json.RawMessage `json:"codeOfConduct,omitempty"`
+	ContactLinks         json.RawMessage `json:"contactLinks,omitempty"`
+	FundingLinks         json.RawMessage `json:"fundingLinks,omitempty"`
+	IssueTemplates       json.RawMessage `json:"issueTemplates,omitempty"`
+	PullRequestTemplates json.RawMessage `json:"pullRequestTemplates,omitempty"`
+	Languages            json.RawMessage `json:"languages,omitempty"`
+	LatestRelease        json.RawMessage `json:"latestRelease,omitempty"`
+	LicenseInfo          json.RawMessage `json:"licenseInfo,omitempty"`
+	Milestones           json.RawMessage `json:"milestones,omitempty"`
+	Parent               json.RawMessage `json:"parent,omitempty"`
+	TemplateRepository   json.RawMessage `json:"templateRepository,omitempty"`
+	Projects             json.RawMessage `json:"projects,omitempty"`
+	ProjectsV2           json.RawMessage `json:"projectsV2,omitempty"`
+	RepositoryTopics     json.RawMessage `json:"repositoryTopics,omitempty"`
+}
+
+type RunStep struct {
+	Number      int    `json:"number"`
+	Name        string `json:"name"`
+	Status      string `json:"status,omitempty"`
+	Conclusion  string `json:"conclusion,omitempty"`
+	StartedAt   string `json:"startedAt,omitempty"`
+	CompletedAt string `json:"completedAt,omitempty"`
+}
+
+type RunJob struct {
+	DatabaseID  int64     `json:"databaseId"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status,omitempty"`
+	Conclusion  string    `json:"conclusion,omitempty"`
+	StartedAt   string    `json:"startedAt,omitempty"`
+	CompletedAt string    `json:"completedAt,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Steps       []RunStep `json:"steps,omitempty"`
+}
+
+type Run struct {
+	DatabaseID         int64    `json:"databaseId,omitempty"`
+	Number             int      `json:"number,omitempty"`
+	Attempt            int      `json:"attempt,omitempty"`
+	Name               string   `json:"name,omitempty"`
+	DisplayTitle       string   `json:"displayTitle,omitempty"`
+	WorkflowName       string   `json:"workflowName,omitempty"`
+	WorkflowDatabaseID int64    `json:"workflowDatabaseId,omitempty"`
+	Event              string   `json:"event,omitempty"`
+	Status             string   `json:"status,omitempty"`
+	Conclusion         string   `json:"conclusion,omitempty"`
+	HeadBranch         string   `json:"headBranch,omitempty"`
+	HeadSha            string   `json:"headSha,omitempty"`
+	CreatedAt          string   `json:"createdAt,omitempty"`
+	StartedAt          string   `json:"startedAt,omitempty"`
+	UpdatedAt          string   `json:"updatedAt,omitempty"`
+	URL                string   `json:"url,omitempty"`
+	Jobs               []RunJob `json:"jobs,omitempty"`
+}
+
+type TreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+	Size int64  `json:"size,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// TreeListing is a directory listing. content_tree prints a bare array
+// unless the call paginates, so both decode to the paginated shape.
+type TreeListing struct {
+	Entries []TreeEntry `json:"entries"`
+	Total   int         `json:"total"`
+	Offset  int         `json:"offset"`
+	Count   int         `json:"count"`
+}
+
+func (l *TreeListing) UnmarshalJSON(data []byte) error {
+	var entries []TreeEntry
+	if err := json.Unmarshal(data, &entries); err == nil {
+		*l = TreeListing{Entries: entries, Total: len(entries), Count: len(entries)}
+		return nil
+	}
+
+	type listing TreeListing
+
+	return json.Unmarshal(data, (*listing)(l))
+}
+
+type BlameAuthor struct {
+	Name string `json:"name"`
+	Date string `json:"date"`
+}
+
+type BlameCommit struct {
+	OID     string      `json:"oid"`
+	Message string      `json:"message"`
+	Author  BlameAuthor `json:"author"`
+}
+
+type BlameRange struct {
+	StartingLine int         `json:"startingLine"`
+	EndingLine   int         `json:"endingLine"`
+	Commit       BlameCommit `json:"commit"`
+}
+
+// Blame is the blame of a file. content_blame prints the GraphQL
+// response, or just the matching ranges when given a line window; both
+// decode to the ranges.
+type Blame struct {
+	Ranges []BlameRange `json:"ranges"`
+}
+
+func (b *Blame) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Ranges); err == nil {
+		return nil
+	}
+
+	var response struct {
+		Data *struct {
+			Repository struct {
+				Object *struct {
+					Blame struct {
+						Ranges []BlameRange `json:"ranges"`
+					} `json:"blame"`
+				} `json:"object"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+
+	if response.Data == nil || response.Data.Repository.Object == nil {
+		return fmt.Errorf("no blame in response")
+	}
+
+	b.Ranges = response.Data.Repository.Object.Blame.Ranges
+
+	return nil
+}
+
+type CommitSummary struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	URL     string `json:"url,omitempty"`
+}
+
+type ChangedFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+}
+
+type Comparison struct {
+	Status       string          `json:"status"`
+	AheadBy      int             `json:"ahead_by"`
+	BehindBy     int             `json:"behind_by"`
+	TotalCommits int             `json:"total_commits"`
+	Commits      []CommitSummary `json:"commits"`
+	Files        []ChangedFile   `json:"files"`
+}
+
+type TextMatch struct {
+	Fragment string `json:"fragment"`
+	Matches  []struct {
+		Text    string `json:"text"`
+		Indices []int  `json:"indices"`
+	} `json:"matches"`
+}
+
+type CodeSearchItem struct {
+	Name        string      `json:"name"`
+	Path        string      `json:"path"`
+	SHA         string      `json:"sha"`
+	URL         string      `json:"url"`
+	Score       float64     `json:"score"`
+	TextMatches []TextMatch `json:"text_matches"`
+}
+
+type CodeSearchResult struct {
+	TotalCount int              `json:"total_count"`
+	Items      []CodeSearchItem `json:"items"`
+}
+
+type RateLimitBudget struct {
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	Used      int    `json:"used"`
+	Reset     string `json:"reset"`
+}
@@ -6,11 +6,10 @@ import (
 	"fmt"
 
 	"github.com/amarbel-llc/go-lib-mcp/protocol"
-	"github.com/amarbel-llc/go-lib-mcp/server"
 	"github.com/friedenberg/get-hubbed/internal/gh"
 )
 
-func registerRunTools(r *server.ToolRegistry) {
+func registerRunTools(r *toolRegistry) {
 	r.Register(
 		"run_list",
 		"List recent workflow runs",
@@ -19,11 +18,13 @@ func registerRunTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"branch": {
 					"type": "string",
-					"description": "Filter runs by branch"
+					"description": "Filter runs by branch",
+					"format": "git-ref"
 				},
 				"status": {
 					"type": "string",
@@ -39,7 +40,8 @@ func registerRunTools(r *server.ToolRegistry) {
 				},
 				"commit": {
 					"type": "string",
-					"description": "Filter runs by commit SHA"
+					"description": "Filter runs by commit SHA",
+					"format": "git-ref"
 				},
 				"user": {
 					"type": "string",
@@ -47,12 +49,22 @@ func registerRunTools(r *server.ToolRegistry) {
 				},
 				"limit": {
 					"type": "integer",
-					"description": "Maximum number of runs to fetch (default 20)"
+					"description": "Maximum number of runs to fetch (default 20)",
+					"minimum": 1
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh run list --json supports"
+				},
+				"jq": {
+					"type": "string",
+					"description": "jq expression gh applies to the JSON output before returning it, e.g. '.[] | {number, title}'"
 				}
-			},
-			"required": ["repo"]
+			}
 		}`),
 		handleRunList,
+		structured[[]Run](),
 	)
 
 	r.Register(
@@ -63,20 +75,29 @@ func registerRunTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"run_id": {
 					"type": "integer",
-					"description": "Workflow run ID"
+					"description": "Workflow run ID",
+					"minimum": 1
 				},
 				"attempt": {
 					"type": "integer",
-					"description": "The attempt number of the workflow run"
+					"description": "The attempt number of the workflow run",
+					"minimum": 1
+				},
+				"fields": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "JSON fields to return instead of the defaults; any field gh run view --json supports"
 				}
 			},
-			"required": ["repo", "run_id"]
+			"required": ["run_id"]
 		}`),
 		handleRunView,
+		structured[Run](),
 	)
 
 	r.Register(
@@ -87,18 +108,21 @@ func registerRunTools(r *server.ToolRegistry) {
 			"properties": {
 				"repo": {
 					"type": "string",
-					"description": "Repository in OWNER/REPO format"
+					"description": "Repository in OWNER/REPO format; defaults to the repository of the current git checkout",
+					"format": "repo"
 				},
 				"run_id": {
 					"type": "integer",
-					"description": "Workflow run ID"
+					"description": "Workflow run ID",
+					"minimum": 1
 				},
 				"job_id": {
 					"type": "integer",
-					"description": "Specific job ID to get logs for (if omitted, shows all failed step logs)"
+					"description": "Specific job ID to get logs for (if omitted, shows all failed step logs)",
+					"minimum": 1
 				}
 			},
-			"required": ["repo", "run_id"]
+			"required": ["run_id"]
 		}`),
 		handleRunLog,
 	)
@@ -106,24 +130,31 @@ func registerRunTools(r *server.ToolRegistry) {
 
 func handleRunList(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
-		Repo     string `json:"repo"`
-		Branch   string `json:"branch"`
-		Status   string `json:"status"`
-		Workflow string `json:"workflow"`
-		Event    string `json:"event"`
-		Commit   string `json:"commit"`
-		User     string `json:"user"`
-		Limit    int    `json:"limit"`
+		Repo     string   `json:"repo"`
+		Branch   string   `json:"branch"`
+		Status   string   `json:"status"`
+		Workflow string   `json:"workflow"`
+		Event    string   `json:"event"`
+		Commit   string   `json:"commit"`
+		User     string   `json:"user"`
+		Limit    int      `json:"limit"`
+		Fields   []string `json:"fields"`
+		JQ       string   `json:"jq"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	fields, err := selectFields(params.Fields, runListFields, "attempt,conclusion,createdAt,databaseId,displayTitle,event,headBranch,headSha,name,number,startedAt,status,updatedAt,url,workflowName")
+	if err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
 	ghArgs := []string{
 		"run", "list",
 		"-R", params.Repo,
-		"--json", "attempt,conclusion,createdAt,databaseId,displayTitle,event,headBranch,headSha,name,number,startedAt,status,updatedAt,url,workflowName",
+		"--json", fields,
 	}
 
 	if params.Branch != "" {
@@ -154,9 +185,13 @@ func handleRunList(ctx context.Context, args json.RawMessage) (*protocol.ToolCal
 		ghArgs = append(ghArgs, "--limit", fmt.Sprintf("%d", params.Limit))
 	}
 
+	if params.JQ != "" {
+		ghArgs = append(ghArgs, "--jq", params.JQ)
+	}
+
 	out, err := gh.Run(ctx, ghArgs...)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh run list: %v", err)), nil
+		return ghErrorResult("gh run list", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -168,19 +203,25 @@ func handleRunList(ctx context.Context, args json.RawMessage) (*protocol.ToolCal
 
 func handleRunView(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
 	var params struct {
-		Repo    string `json:"repo"`
-		RunID   int64  `json:"run_id"`
-		Attempt int    `json:"attempt"`
+		Repo    string   `json:"repo"`
+		RunID   int64    `json:"run_id"`
+		Attempt int      `json:"attempt"`
+		Fields  []string `json:"fields"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+	}
+
+	fields, err := selectFields(params.Fields, runViewFields, "attempt,conclusion,createdAt,databaseId,displayTitle,event,headBranch,headSha,jobs,name,number,startedAt,status,updatedAt,url,workflowDatabaseId,workflowName")
+	if err != nil {
+		return errorResult(gh.KindValidation, "%v", err), nil
 	}
 
 	ghArgs := []string{
 		"run", "view", fmt.Sprintf("%d", params.RunID),
 		"-R", params.Repo,
-		"--json", "attempt,conclusion,createdAt,databaseId,displayTitle,event,headBranch,headSha,jobs,name,number,startedAt,status,updatedAt,url,workflowDatabaseId,workflowName",
+		"--json", fields,
 	}
 
 	if params.Attempt > 0 {
@@ -189,7 +230,7 @@ func handleRunView(ctx context.Context, args json.RawMessage) (*protocol.ToolCal
 
 	out, err := gh.Run(ctx, ghArgs...)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh run view: %v", err)), nil
+		return ghErrorResult("gh run view", err), nil
 	}
 
 	return &protocol.ToolCallResult{
@@ -207,7 +248,7 @@ func handleRunLog(ctx context.Context, args json.RawMessage) (*protocol.ToolCall
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
 	}
 
 	ghArgs := []string{
@@ -222,7 +263,7 @@ func handleRunLog(ctx context.Context, args json.RawMessage) (*protocol.ToolCall
 
 	out, err := gh.Run(ctx, ghArgs...)
 	if err != nil {
-		return protocol.ErrorResult(fmt.Sprintf("gh run view log: %v", err)), nil
+		return ghErrorResult("gh run view log", err), nil
 	}
 
 	if out == "" {
@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/annotate"
+	"github.com/friedenberg/get-hubbed/internal/redact"
+)
+
+// structured declares T as the shape of a tool's JSON output. Each result
+// is decoded into a T and returned again as structuredContent, and T's
+// JSON schema becomes the tool's outputSchema. MCP structured content is
+// always an object, so a slice type is wrapped as {"items": [...]}. Tools
+// whose output is free text (file contents, logs, diffs) or JSON whose
+// shape the caller picks (api_get, graphql_query, graphql_mutation) do not
+// declare one.
+func structured[T any]() toolOption {
+	return func(t *tool) {
+		typ := reflect.TypeFor[T]()
+		schema := jsonSchema(typ)
+
+		if typ.Kind() == reflect.Slice {
+			schema = map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"items": schema},
+				"required":   []string{"items"},
+			}
+		}
+
+		t.outputSchema, _ = json.Marshal(schema)
+		t.decode = decodeStructured[T]
+	}
+}
+
+// decodeStructured checks text decodes as a T and returns it as structured
+// content. Text that decodes into T unchanged is returned as it is, so the
+// structured copy has exactly the text's fields, zero values included;
+// only types with their own UnmarshalJSON, which fold several output
+// shapes into one, are re-encoded.
+func decodeStructured[T any](text string) (json.RawMessage, error) {
+	var v T
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return nil, err
+	}
+
+	var out any = v
+	if _, reshaped := any(&v).(json.Unmarshaler); !reshaped {
+		out = json.RawMessage(text)
+	}
+
+	if reflect.TypeFor[T]().Kind() == reflect.Slice {
+		out = map[string]any{"items": out}
+	}
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonSchema describes how encoding/json renders values of type t. Struct
+// fields without omitempty are required.
+func jsonSchema(t reflect.Type) map[string]any {
+	if t == reflect.TypeFor[json.RawMessage]() {
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			properties[name] = jsonSchema(field.Type)
+
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+
+		return schema
+	}
+
+	return map[string]any{}
+}
+
+type structuredKey struct{}
+
+// structuredSlot carries a call's structured content from
+// withStructuredDecode, next to the handler, out to withStructuredContent,
+// past the wrappers that reformat and chunk the text.
+type structuredSlot struct {
+	content json.RawMessage
+}
+
+// withStructuredDecode decodes a handler's JSON output into the tool's
+// result type. Output that no longer matches the type is logged and the
+// call returns its text alone, rather than a silently different shape or
+// an error for a call that succeeded. Calls with a jq filter return
+// whatever the filter builds, and calls choosing their fields return only
+// those, so neither carries structured content.
+func withStructuredDecode(name string, decode func(string) (json.RawMessage, error), handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		result, err := handler(ctx, args)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+
+		slot, _ := ctx.Value(structuredKey{}).(*structuredSlot)
+		if slot == nil {
+			return result, nil
+		}
+
+		var params struct {
+			JQ     string   `json:"jq"`
+			Fields []string `json:"fields"`
+		}
+
+		_ = json.Unmarshal(args, &params)
+
+		if params.JQ != "" || len(params.Fields) > 0 {
+			return result, nil
+		}
+
+		var sb strings.Builder
+		for _, block := range result.Content {
+			sb.WriteString(block.Text)
+		}
+
+		content, err := decode(sb.String())
+		if err != nil {
+			log.Printf("%s output does not match its output schema: %v", name, err)
+
+			return result, nil
+		}
+
+		slot.content = content
+
+		return result, nil
+	}
+}
+
+// dropStructuredContent discards the structured content of the call in
+// ctx. withBudget uses it for chunked results: the structured copy would
+// carry the whole output the chunk withholds.
+func dropStructuredContent(ctx context.Context) {
+	if slot, _ := ctx.Value(structuredKey{}).(*structuredSlot); slot != nil {
+		slot.content = nil
+	}
+}
+
+// withStructuredContent appends the structured content withStructuredDecode
+// found as a block of type annotate.StructuredContentType, which the stdio
+// writer moves to the result's structuredContent.
+func withStructuredContent(r *redact.Redactor, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		slot := &structuredSlot{}
+
+		result, err := handler(context.WithValue(ctx, structuredKey{}, slot), args)
+		if err != nil || result == nil || result.IsError || slot.content == nil {
+			return result, err
+		}
+
+		text := string(slot.content)
+		if r != nil {
+			text = r.Redact(text)
+		}
+
+		result.Content = append(result.Content, protocol.ContentBlock{
+			Type: annotate.StructuredContentType,
+			Text: text,
+		})
+
+		return result, nil
+	}
+}
+
+// OutputSchemas returns the output schema of every tool that declares one,
+// keyed by name.
+func OutputSchemas() map[string]json.RawMessage {
+	r := newToolRegistry(Options{})
+	registerTools(r)
+
+	schemas := make(map[string]json.RawMessage)
+	for _, t := range r.tools {
+		if t.outputSchema != nil {
+			schemas[t.name] = t.outputSchema
+		}
+	}
+
+	return schemas
+}
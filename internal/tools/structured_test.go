@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/annotate"
+)
+
+func TestOutputSchemasAreObjects(t *testing.T) {
+	for name, raw := range OutputSchemas() {
+		var schema struct {
+			Type       string         `json:"type"`
+			Properties map[string]any `json:"properties"`
+		}
+
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if schema.Type != "object" {
+			t.Errorf("%s output schema has type %q, want object", name, schema.Type)
+		}
+	}
+}
+
+func TestStructuredListIsWrapped(t *testing.T) {
+	var tl tool
+	structured[[]CommitSummary]()(&tl)
+
+	got, err := tl.decode(`[{"sha": "abc", "message": "m", "author": "a", "date": "d"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"items":[{"sha":"abc","message":"m","author":"a","date":"d"}]}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	var schema struct {
+		Required []string `json:"required"`
+	}
+
+	_ = json.Unmarshal(tl.outputSchema, &schema)
+
+	if len(schema.Required) != 1 || schema.Required[0] != "items" {
+		t.Errorf("schema = %s", tl.outputSchema)
+	}
+}
+
+func TestStructuredRejectsDrift(t *testing.T) {
+	if _, err := decodeStructured[Comparison](`{"ahead_by": "three"}`); err == nil {
+		t.Error("expected an error for a mistyped field")
+	}
+}
+
+func TestStructuredContentChain(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         string
+		budget         int
+		wantStructured bool
+	}{
+		{"decoded", `{"status": "ahead", "ahead_by": 1}`, 0, true},
+		{"drift keeps the text", `{"ahead_by": "three"}`, 0, false},
+		{"chunked", `{"status": "ahead", "ahead_by": 1}`, 10, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+				return &protocol.ToolCallResult{Content: []protocol.ContentBlock{protocol.TextContent(tt.output)}}, nil
+			}
+
+			h := withStructuredDecode("content_compare", decodeStructured[Comparison], handler)
+			if tt.budget > 0 {
+				h = withBudget("content_compare", tt.budget, newOutputStore(), h)
+			}
+
+			result, err := withStructuredContent(nil, h)(context.Background(), json.RawMessage(`{}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.IsError {
+				t.Fatalf("unexpected error %q", result.Content[0].Text)
+			}
+
+			var structured bool
+			for _, block := range result.Content {
+				structured = structured || block.Type == annotate.StructuredContentType
+			}
+
+			if structured != tt.wantStructured {
+				t.Errorf("structured content present = %v, want %v", structured, tt.wantStructured)
+			}
+		})
+	}
+}
+
+func TestStructuredKeepsTextFields(t *testing.T) {
+	got, err := decodeStructured[Repository](`{"name": "spoon", "description": "", "isPrivate": false, "stargazerCount": 0}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"name":"spoon","description":"","isPrivate":false,"stargazerCount":0}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestStructuredContentSkipsChosenFields(t *testing.T) {
+	handler := func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		return &protocol.ToolCallResult{Content: []protocol.ContentBlock{protocol.TextContent(`{"number": 1, "title": "t"}`)}}, nil
+	}
+
+	h := withStructuredContent(nil, withStructuredDecode("issue_view", decodeStructured[Issue], handler))
+
+	for args, want := range map[string]bool{
+		`{}`:                              true,
+		`{"fields": ["number", "title"]}`: false,
+		`{"jq": ".number"}`:               false,
+	} {
+		result, err := h(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var structured bool
+		for _, block := range result.Content {
+			structured = structured || block.Type == annotate.StructuredContentType
+		}
+
+		if structured != want {
+			t.Errorf("args %s: structured content present = %v, want %v", args, structured, want)
+		}
+	}
+}
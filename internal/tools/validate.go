@@ -0,0 +1,303 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+	"github.com/friedenberg/get-hubbed/internal/gh"
+)
+
+// argSchema is the subset of JSON Schema the tool input schemas use.
+type argSchema struct {
+	Type       string                `json:"type"`
+	Properties map[string]*argSchema `json:"properties"`
+	Required   []string              `json:"required"`
+	Items      *argSchema            `json:"items"`
+	Enum       []any                 `json:"enum"`
+	Minimum    *float64              `json:"minimum"`
+	Maximum    *float64              `json:"maximum"`
+	Format     string                `json:"format"`
+}
+
+// argFormats are the string formats tool schemas can declare, checked
+// before any value reaches gh as an argument or URL segment.
+var argFormats = map[string]func(string) error{
+	"repo":      validateRepo,
+	"owner":     validateOwner,
+	"git-ref":   validateRef,
+	"head-ref":  validateHeadRef,
+	"repo-path": validatePath,
+}
+
+// withValidation checks every call against the tool's input schema and
+// returns a validation error listing each problem instead of running the
+// handler. Arguments a schema does not declare are rejected too, since a
+// misspelt optional argument would otherwise be silently ignored.
+func withValidation(schema json.RawMessage, handler handlerFunc) handlerFunc {
+	var s argSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		panic(fmt.Sprintf("invalid tool schema: %v", err))
+	}
+
+	return func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		if len(bytes.TrimSpace(args)) == 0 || bytes.Equal(bytes.TrimSpace(args), []byte("null")) {
+			args = json.RawMessage(`{}`)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(args))
+		dec.UseNumber()
+
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return errorResult(gh.KindValidation, "invalid arguments: %v", err), nil
+		}
+
+		if problems := s.validate("", v); len(problems) > 0 {
+			return errorResult(gh.KindValidation, "invalid arguments: %s", strings.Join(problems, "; ")), nil
+		}
+
+		return handler(ctx, args)
+	}
+}
+
+func (s *argSchema) validate(path string, v any) []string {
+	name := path
+	if name == "" {
+		name = "arguments"
+	}
+
+	var problems []string
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s must be an object", name)}
+		}
+
+		for _, key := range s.Required {
+			if value, ok := obj[key]; !ok || value == nil {
+				problems = append(problems, fmt.Sprintf("%s is required", join(path, key)))
+			}
+		}
+
+		if s.Properties == nil {
+			// A free-form object such as GraphQL variables.
+			break
+		}
+
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("unknown argument %s", join(path, key)))
+				continue
+			}
+
+			// Clients often send null for an argument they leave unset.
+			if obj[key] == nil {
+				continue
+			}
+
+			problems = append(problems, prop.validate(join(path, key), obj[key])...)
+		}
+
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s must be an array", name)}
+		}
+
+		if s.Items != nil {
+			for i, item := range items {
+				problems = append(problems, s.Items.validate(fmt.Sprintf("%s[%d]", name, i), item)...)
+			}
+		}
+
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s must be a string", name)}
+		}
+
+		if check, ok := argFormats[s.Format]; ok && str != "" {
+			if err := check(str); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return []string{fmt.Sprintf("%s must be a%s", name, article(s.Type))}
+		}
+
+		// Handlers decode integers into int fields, which reject 1e2 and
+		// 1.0 as well as 1.5, so only plain digits pass.
+		if s.Type == "integer" {
+			if _, err := strconv.ParseInt(n.String(), 10, 64); err != nil {
+				return []string{fmt.Sprintf("%s must be an integer, got %s", name, n)}
+			}
+		}
+
+		f, err := n.Float64()
+		if err != nil {
+			return []string{fmt.Sprintf("%s must be a%s, got %s", name, article(s.Type), n)}
+		}
+
+		if s.Minimum != nil && f < *s.Minimum {
+			problems = append(problems, fmt.Sprintf("%s must be at least %g, got %s", name, *s.Minimum, n))
+		}
+
+		if s.Maximum != nil && f > *s.Maximum {
+			problems = append(problems, fmt.Sprintf("%s must be at most %g, got %s", name, *s.Maximum, n))
+		}
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{fmt.Sprintf("%s must be a boolean", name)}
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(v) }) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+
+		problems = append(problems, fmt.Sprintf("%s must be one of %s, got %v", name, strings.Join(allowed, ", "), v))
+	}
+
+	return problems
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func article(typ string) string {
+	if typ == "integer" {
+		return "n integer"
+	}
+
+	return " number"
+}
+
+var (
+	ownerPattern    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,99}$`)
+	repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+	hostPattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]*(:[0-9]+)?$`)
+)
+
+// validateRepo accepts OWNER/REPO, optionally prefixed with a host.
+func validateRepo(repo string) error {
+	parts := strings.Split(repo, "/")
+
+	if len(parts) == 3 {
+		if !hostPattern.MatchString(parts[0]) {
+			return fmt.Errorf("%q does not start with a valid host", repo)
+		}
+
+		parts = parts[1:]
+	}
+
+	if len(parts) != 2 {
+		return fmt.Errorf("%q is not in OWNER/REPO format", repo)
+	}
+
+	if err := validateOwner(parts[0]); err != nil {
+		return err
+	}
+
+	if !repoNamePattern.MatchString(parts[1]) || parts[1] == "." || parts[1] == ".." {
+		return fmt.Errorf("%q is not a valid repository name", parts[1])
+	}
+
+	return nil
+}
+
+// validateOwner accepts a GitHub user or organization login.
+func validateOwner(owner string) error {
+	if !ownerPattern.MatchString(owner) {
+		return fmt.Errorf("%q is not a valid user or organization name", owner)
+	}
+
+	return nil
+}
+
+// validateRef applies git's ref name rules (see git-check-ref-format),
+// which also keep a ref from being read as a gh flag or splitting the
+// ref:path expressions the tree and blame tools build.
+func validateRef(ref string) error {
+	reason := ""
+
+	switch {
+	case strings.HasPrefix(ref, "-"):
+		reason = "must not start with -"
+	case ref == "@":
+		reason = "must not be @"
+	case strings.ContainsFunc(ref, func(r rune) bool { return r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) }):
+		reason = "must not contain spaces, control characters or any of ~^:?*[\\"
+	case strings.Contains(ref, ".."), strings.Contains(ref, "@{"), strings.Contains(ref, "//"):
+		reason = "must not contain .., @{ or //"
+	case strings.HasPrefix(ref, "/"), strings.HasSuffix(ref, "/"), strings.HasSuffix(ref, "."), strings.HasSuffix(ref, ".lock"):
+		reason = "must not start or end with /, or end with . or .lock"
+	case strings.HasPrefix(ref, ".") || strings.Contains(ref, "/."):
+		reason = "no component may start with ."
+	}
+
+	if reason != "" {
+		return fmt.Errorf("%q is not a valid git ref: %s", ref, reason)
+	}
+
+	return nil
+}
+
+// validateHeadRef accepts a branch, or OWNER:branch for a branch in a
+// fork.
+func validateHeadRef(head string) error {
+	if owner, branch, ok := strings.Cut(head, ":"); ok {
+		if err := validateOwner(owner); err != nil {
+			return err
+		}
+
+		head = branch
+	}
+
+	return validateRef(head)
+}
+
+// validatePath accepts a path relative to the repository root.
+func validatePath(path string) error {
+	if strings.HasPrefix(path, "/") {
+		return fmt.Errorf("%q must be relative to the repository root", path)
+	}
+
+	if strings.ContainsFunc(path, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return fmt.Errorf("%q contains control characters", path)
+	}
+
+	if slices.Contains(strings.Split(path, "/"), "..") {
+		return fmt.Errorf("%q must not contain .. components", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/go-lib-mcp/protocol"
+)
+
+func TestValidateRepo(t *testing.T) {
+	for repo, valid := range map[string]bool{
+		"octo/hello":                 true,
+		"octo/hello.go":              true,
+		"ghe.example.com/octo/hello": true,
+		"hello":                      false,
+		"octo/hello/extra/more":      false,
+		"-R/hello":                   false,
+		"octo/..":                    false,
+		"octo/hel lo":                false,
+	} {
+		if err := validateRepo(repo); (err == nil) != valid {
+			t.Errorf("validateRepo(%q) = %v, want valid %v", repo, err, valid)
+		}
+	}
+}
+
+func TestValidateRef(t *testing.T) {
+	for ref, valid := range map[string]bool{
+		"main":           true,
+		"release/v1.2":   true,
+		"HEAD":           true,
+		"0123abcd":       true,
+		"-x":             false,
+		"main..dev":      false,
+		"main:src":       false,
+		"feature/":       false,
+		"refs/.hidden":   false,
+		"topic.lock":     false,
+		"HEAD@{1}":       false,
+		"with space":     false,
+		"tab\tseparated": false,
+	} {
+		if err := validateRef(ref); (err == nil) != valid {
+			t.Errorf("validateRef(%q) = %v, want valid %v", ref, err, valid)
+		}
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	for path, valid := range map[string]bool{
+		"src/main.go":   true,
+		"docs/..hidden": true,
+		"/etc/passwd":   false,
+		"src/../..":     false,
+		"a\x00b":        false,
+	} {
+		if err := validatePath(path); (err == nil) != valid {
+			t.Errorf("validatePath(%q) = %v, want valid %v", path, err, valid)
+		}
+	}
+}
+
+func TestWithValidation(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"number": {"type": "integer", "minimum": 1},
+			"state": {"type": "string", "enum": ["open", "closed"]},
+			"labels": {"type": "array", "items": {"type": "string"}},
+			"variables": {"type": "object"}
+		},
+		"required": ["number"]
+	}`)
+
+	var called bool
+
+	handler := withValidation(schema, func(ctx context.Context, args json.RawMessage) (*protocol.ToolCallResult, error) {
+		called = true
+		return &protocol.ToolCallResult{}, nil
+	})
+
+	for args, want := range map[string]string{
+		`{"number": 3, "state": null, "variables": {"any": 1}}`: "",
+		`{}`:                           "number is required",
+		`{"number": 1.5}`:              "number must be an integer, got 1.5",
+		`{"number": 1e2}`:              "number must be an integer, got 1e2",
+		`{"number": 1.0}`:              "number must be an integer, got 1.0",
+		`{"number": 0}`:                "number must be at least 1, got 0",
+		`{"number": "3"}`:              "number must be an integer",
+		`{"number": 3, "state": "x"}`:  "state must be one of open, closed, got x",
+		`{"number": 3, "labels": [1]}`: "labels[0] must be a string",
+		`{"number": 3, "nubmer": 3}`:   "unknown argument nubmer",
+	} {
+		called = false
+
+		result, err := handler(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want == "" {
+			if result.IsError || !called {
+				t.Errorf("%s: rejected valid arguments: %v", args, result.Content)
+			}
+
+			continue
+		}
+
+		if !result.IsError || called {
+			t.Errorf("%s: handler ran despite invalid arguments", args)
+			continue
+		}
+
+		if !strings.Contains(result.Content[0].Text, want) {
+			t.Errorf("%s: error %s does not mention %q", args, result.Content[0].Text, want)
+		}
+	}
+}